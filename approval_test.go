@@ -0,0 +1,106 @@
+// Copyright © 2022, Electron Labs
+
+package light
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/electron-labs/near-light-client-go/mock"
+	"github.com/electron-labs/near-light-client-go/nearprimitive"
+)
+
+func TestValidateLightBlockRejectsSkipApprovals(t *testing.T) {
+	h := mock.MockHostFunction{}
+	validators := new_synthetic_validators(t, "bp", 4)
+	next_validators := new_synthetic_stake_views(t, "next", 4)
+
+	head := new_synthetic_block(t, h, 100, synthetic_epoch_0, synthetic_epoch_1, synthetic_stake_views(validators), 0)
+	producers := map[nearprimitive.CryptoHash][]nearprimitive.ValidatorStakeView{
+		synthetic_epoch_1: synthetic_stake_views(validators),
+	}
+
+	// The next block is at 111, producers skipped 112 and approved it for 113
+	block_view := new_synthetic_block(t, h, 110, synthetic_epoch_1, synthetic_epoch_2, next_validators, 1)
+	approval_message, err := nearprimitive.ApprovalMessage(nearprimitive.NewSkip(111), 113)
+	if err != nil {
+		t.Fatalf("Failed to build approval message: %s", err)
+	}
+	sign_synthetic_approvals(t, h, &block_view, approval_message, validators, all_sign)
+
+	err = ValidateLightBlock(h, &head, &block_view, producers)
+	if !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected ErrInvalidSignature, got %v", err)
+	}
+
+	// The skip approvals don't commit to the block, reused on a forged block
+	// of the same epoch they must not validate it either
+	forged := new_synthetic_block(t, h, 110, synthetic_epoch_1, synthetic_epoch_2, new_synthetic_stake_views(t, "forged", 4), 2)
+	forged.InnerLite.OutcomeRoot = nearprimitive.CryptoHash{0xff}
+	forged.ApprovalsAfterNext = block_view.ApprovalsAfterNext
+
+	report, err := ValidateLightBlockDetailed(h, &head, &forged, producers)
+	if !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected ErrInvalidSignature, got %v", err)
+	}
+
+	if !report.ApprovedStake.IsZero() {
+		t.Errorf("Reused skip approvals were counted: %s", report.ApprovedStake)
+	}
+
+	// Endorsements still verify
+	endorsed := new_synthetic_block(t, h, 110, synthetic_epoch_1, synthetic_epoch_2, next_validators, 1)
+	sign_synthetic_block(t, h, &endorsed, validators, all_sign)
+
+	report, err = ValidateLightBlockDetailed(h, &head, &endorsed, producers)
+	if err != nil {
+		t.Errorf("Failed to validate block: %s", err)
+	}
+
+	expected, err := nearprimitive.ApprovalMessage(nearprimitive.NewEndorsement(report.NextBlockHash), 112)
+	if err != nil || !bytes.Equal(report.ApprovalMessage, expected) {
+		t.Errorf("Unexpected approval message %x", report.ApprovalMessage)
+	}
+}
+
+func TestValidateLightBlockExtraApprovals(t *testing.T) {
+	h := mock.MockHostFunction{}
+	validators := new_synthetic_validators(t, "bp", 4)
+	next_validators := new_synthetic_stake_views(t, "next", 4)
+
+	head := new_synthetic_block(t, h, 100, synthetic_epoch_0, synthetic_epoch_1, synthetic_stake_views(validators), 0)
+	producers := map[nearprimitive.CryptoHash][]nearprimitive.ValidatorStakeView{
+		synthetic_epoch_1: synthetic_stake_views(validators),
+	}
+
+	// Signed by one more validator than the epoch has, the extra approval is
+	// ignored
+	block_view := new_synthetic_block(t, h, 110, synthetic_epoch_1, synthetic_epoch_2, next_validators, 1)
+	sign_synthetic_block(t, h, &block_view, new_synthetic_validators(t, "bp", 5), all_sign)
+
+	report, err := ValidateLightBlockDetailed(h, &head, &block_view, producers)
+	if err != nil {
+		t.Fatalf("Failed to validate block: %s", err)
+	}
+
+	if len(report.Approvals) != 4 {
+		t.Errorf("Expected 4 approvals, got %d", len(report.Approvals))
+	}
+
+	// Even if it doesn't verify
+	block_view.ApprovalsAfterNext = append(block_view.ApprovalsAfterNext, &nearprimitive.Signature{})
+
+	err = ValidateLightBlock(h, &head, &block_view, producers)
+	if err != nil {
+		t.Errorf("Failed to validate block: %s", err)
+	}
+
+	// Too few approvals are still rejected
+	block_view.ApprovalsAfterNext = block_view.ApprovalsAfterNext[:3]
+
+	err = ValidateLightBlock(h, &head, &block_view, producers)
+	if !errors.Is(err, ErrApprovalCountMismatch) {
+		t.Errorf("Expected ErrApprovalCountMismatch, got %v", err)
+	}
+}
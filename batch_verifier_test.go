@@ -0,0 +1,161 @@
+// Copyright © 2022, Electron Labs
+
+package light
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"testing"
+
+	"github.com/electron-labs/near-light-client-go/mock"
+	"github.com/electron-labs/near-light-client-go/nearprimitive"
+)
+
+// counting_batch_host records the batches it was asked to verify.
+type counting_batch_host struct {
+	mock.BatchHostFunction
+	batch_sizes *[]int
+}
+
+func (c counting_batch_host) VerifyBatch(msgs [][]byte, sigs []nearprimitive.Signature, keys []nearprimitive.PublicKey) []bool {
+	*c.batch_sizes = append(*c.batch_sizes, len(sigs))
+	return c.BatchHostFunction.VerifyBatch(msgs, sigs, keys)
+}
+
+func TestValidateLightBlockUsesBatchVerifier(t *testing.T) {
+	pre_epoch, err := GetClientBlockView(CLIENT_RESPONSE_PREVIOUS_EPOCH)
+	if err != nil {
+		t.Errorf("Failed to parse prev client block: %s", err)
+	}
+
+	curr_epoch, err := GetClientBlockView(CLIENT_BLOCK_RESPONSE)
+	if err != nil {
+		t.Errorf("Failed to parse current client block: %s", err)
+	}
+
+	producers := map[nearprimitive.CryptoHash][]nearprimitive.ValidatorStakeView{
+		pre_epoch.InnerLite.NextEpochId: pre_epoch.NextBps,
+	}
+
+	batch_sizes := []int{}
+	h := counting_batch_host{batch_sizes: &batch_sizes}
+
+	report, err := ValidateLightBlockDetailed(h, &pre_epoch, &curr_epoch, producers)
+	if err != nil {
+		t.Fatalf("Failed to validate block: %s", err)
+	}
+
+	expected_report, err := ValidateLightBlockDetailed(mock.MockHostFunction{}, &pre_epoch, &curr_epoch, producers)
+	if err != nil {
+		t.Fatalf("Failed to validate block: %s", err)
+	}
+
+	signed := 0
+	for i, approval := range report.Approvals {
+		if approval.Status != expected_report.Approvals[i].Status {
+			t.Errorf("Approval %d: expected %s, got %s", i, expected_report.Approvals[i].Status, approval.Status)
+		}
+
+		if approval.Status == ApprovalSigned {
+			signed++
+		}
+	}
+
+	if len(batch_sizes) != 1 || batch_sizes[0] != signed {
+		t.Errorf("Expected a single batch of %d signatures, got %v", signed, batch_sizes)
+	}
+}
+
+func TestBatchVerifierInvalidSignature(t *testing.T) {
+	h := mock.BatchHostFunction{}
+	validators := new_synthetic_validators(t, "bp", 4)
+	next_validators := new_synthetic_stake_views(t, "next", 4)
+
+	head := new_synthetic_block(t, h, 100, synthetic_epoch_0, synthetic_epoch_1, synthetic_stake_views(validators), 0)
+	producers := map[nearprimitive.CryptoHash][]nearprimitive.ValidatorStakeView{
+		synthetic_epoch_1: synthetic_stake_views(validators),
+	}
+
+	block_view := new_synthetic_block(t, h, 110, synthetic_epoch_1, synthetic_epoch_2, next_validators, 1)
+	sign_synthetic_block(t, h, &block_view, validators, all_sign)
+
+	_, err := ValidateLightBlockDetailed(h, &head, &block_view, producers)
+	if err != nil {
+		t.Errorf("Failed to validate block: %s", err)
+	}
+
+	// Flip a bit of the third approval
+	forged_signature := *block_view.ApprovalsAfterNext[2]
+	forged_signature[0] ^= 1
+	block_view.ApprovalsAfterNext[2] = &forged_signature
+
+	report, err := ValidateLightBlockDetailed(h, &head, &block_view, producers)
+	var signature_err *InvalidSignatureError
+	if !errors.As(err, &signature_err) || signature_err.Index != 2 {
+		t.Fatalf("Expected an invalid signature at index 2, got %v", err)
+	}
+
+	for i, approval := range report.Approvals {
+		expected := ApprovalSigned
+		if i == 2 {
+			expected = ApprovalInvalid
+		}
+
+		if approval.Status != expected {
+			t.Errorf("Approval %d: expected %s, got %s", i, expected, approval.Status)
+		}
+	}
+}
+
+func TestVerifyBatch(t *testing.T) {
+	validators := new_synthetic_validators(t, "bp", 8)
+
+	msgs := [][]byte{}
+	sigs := []nearprimitive.Signature{}
+	keys := []nearprimitive.PublicKey{}
+
+	for i, v := range validators {
+		msg := []byte{byte(i), 1, 2, 3}
+
+		signature := nearprimitive.Signature{}
+		err := signature.TryFromRaw(ed25519.Sign(v.private_key, msg))
+		if err != nil {
+			t.Fatalf("Failed to create signature: %s", err)
+		}
+
+		msgs = append(msgs, msg)
+		sigs = append(sigs, signature)
+		keys = append(keys, v.stake_view.V1.PublicKey.ED25519.Inner)
+	}
+
+	// Signed by someone else, and a non canonical s
+	sigs[3] = sigs[4]
+	sigs[5][63] |= 0xf0
+
+	for _, h := range []nearprimitive.HostFunction{mock.MockHostFunction{}, mock.BatchHostFunction{}} {
+		res := nearprimitive.VerifyBatch(h, msgs, sigs, keys)
+		if len(res) != len(sigs) {
+			t.Fatalf("%T: expected %d results, got %d", h, len(sigs), len(res))
+		}
+
+		for i, valid := range res {
+			if valid != (i != 3 && i != 5) {
+				t.Errorf("%T: unexpected result %v for signature %d", h, valid, i)
+			}
+		}
+
+		res = nearprimitive.VerifyBatch(h, msgs[:2], sigs[:2], keys[:2])
+		if !res[0] || !res[1] {
+			t.Errorf("%T: failed to verify a valid batch", h)
+		}
+
+		res = nearprimitive.VerifyBatch(h, msgs[:1], sigs[:2], keys[:2])
+		if res[0] || res[1] {
+			t.Errorf("%T: verified a batch with missing messages", h)
+		}
+
+		if len(nearprimitive.VerifyBatch(h, nil, nil, nil)) != 0 {
+			t.Errorf("%T: unexpected results for an empty batch", h)
+		}
+	}
+}
@@ -5,48 +5,60 @@ import (
 	"encoding/json"
 	"fmt"
 
-	"github.com/electron-labs/near-light-client-go/mock"
 	"github.com/electron-labs/near-light-client-go/nearprimitive"
 	borsh "github.com/near/borsh-go"
 )
 
-func BlockMerkleRootVerification(lcResp string, execResp string) error {
-	nlc_json := NearLightClientBlockView{}
-	err := json.Unmarshal([]byte(lcResp), &nlc_json)
+func block_lite_view_hash(h nearprimitive.HostFunction, block_lite_view nearprimitive.LightClientBlockLiteView) (nearprimitive.CryptoHash, error) {
+	ser_inner_lite, err := borsh.Serialize(block_lite_view.InnerLite.ToBlockHeaderInnerLiteViewFinal())
 	if err != nil {
-		return fmt.Errorf("Failed to parse light client block: %s", err)
+		return nearprimitive.CryptoHash{}, fmt.Errorf("Failed to serialize: %w", err)
 	}
 
-	tx_proof_json, err := GetTxProof(execResp)
+	sha_inner_lite := h.Sha256(ser_inner_lite)
+
+	return CurrentBlockHash(h, sha_inner_lite, block_lite_view.InnerRestHash, block_lite_view.PrevBlockHash), nil
+}
+
+func verify_block_merkle_root(h nearprimitive.HostFunction, tx_proof NearTxResult, block_merkle_root nearprimitive.CryptoHash) error {
+	re, err := block_lite_view_hash(h, tx_proof.BlockHeaderLite)
 	if err != nil {
-		return fmt.Errorf("Failed to parse tx proof: %s", err)
+		return fmt.Errorf("Failed to hash block header lite: %w", err)
 	}
 
-	tx_proof, err := tx_proof_json.parse()
+	root, err := compute_root_from_path(h, tx_proof.BlockProof, nearprimitive.MerkleHash(re))
 	if err != nil {
-		return fmt.Errorf("Failed to parse tx_proof: %s", err)
+		return fmt.Errorf("Failed to compute root: %w", err)
 	}
 
-	ser_inner_lite, err := borsh.Serialize(tx_proof.BlockHeaderLite.InnerLite.ToBlockHeaderInnerLiteViewFinal())
-	if err != nil {
-		return fmt.Errorf("Failed to serialize: %s", err)
+	if !bytes.Equal(block_merkle_root[:], root[:]) {
+		return fmt.Errorf("%w: expected %v, got %v", ErrBlockMerkleRootMismatch, block_merkle_root, nearprimitive.CryptoHash(root))
 	}
 
-	h := mock.MockHostFunction{}
+	return nil
+}
 
-	sha_inner_lite := h.Sha256(ser_inner_lite)
-	re := CurrentBlockHash(h, sha_inner_lite, tx_proof.BlockHeaderLite.InnerRestHash, tx_proof.BlockHeaderLite.PrevBlockHash)
+func BlockMerkleRootVerification(h nearprimitive.HostFunction, lcResp string, execResp string) error {
+	nlc_json := NearLightClientBlockView{}
+	err := json.Unmarshal([]byte(lcResp), &nlc_json)
+	if err != nil {
+		return fmt.Errorf("Failed to parse light client block: %w", err)
+	}
 
-	near_light_client_block_view := nlc_json.parse()
+	tx_proof_json, err := GetTxProof(execResp)
+	if err != nil {
+		return fmt.Errorf("Failed to parse tx proof: %w", err)
+	}
 
-	root, err := compute_root_from_path(h, tx_proof.BlockProof, nearprimitive.MerkleHash(re))
+	tx_proof, err := tx_proof_json.parse()
 	if err != nil {
-		return fmt.Errorf("Failed to compute root: %s", err)
+		return fmt.Errorf("Failed to parse tx_proof: %w", err)
 	}
 
-	if !bytes.Equal(near_light_client_block_view.InnerLite.BlockMerkleRoot[:], root[:]) {
-		return fmt.Errorf("Failed to verify merkle root!")
-	} else {
-		return nil
+	near_light_client_block_view, err := nlc_json.parse()
+	if err != nil {
+		return fmt.Errorf("Failed to parse light client block: %w", err)
 	}
+
+	return verify_block_merkle_root(h, tx_proof, near_light_client_block_view.InnerLite.BlockMerkleRoot)
 }
@@ -3,13 +3,14 @@
 package light
 
 import (
-	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"math"
+	"sync"
+	"time"
 
 	"github.com/electron-labs/near-light-client-go/nearprimitive"
 	borsh "github.com/near/borsh-go"
-	num "github.com/shabbyrobe/go-num"
 )
 
 func GetClientBlockView(client_block_response string) (nearprimitive.LightClientBlockView, error) {
@@ -17,10 +18,10 @@ func GetClientBlockView(client_block_response string) (nearprimitive.LightClient
 
 	err := json.Unmarshal([]byte(client_block_response), &block_view)
 	if err != nil {
-		return nearprimitive.LightClientBlockView{}, fmt.Errorf("Failed to parse client block: %s", err)
+		return nearprimitive.LightClientBlockView{}, fmt.Errorf("Failed to parse client block: %w", err)
 	}
 
-	return block_view.parse(), nil
+	return block_view.parse()
 }
 
 func next_block_hash(h nearprimitive.HostFunction, next_block_inner_hash nearprimitive.CryptoHash, current_block_hash nearprimitive.CryptoHash) (nearprimitive.CryptoHash, error) {
@@ -51,92 +52,298 @@ func reconstruct_light_client_block_view_fields(h nearprimitive.HostFunction, bl
 		return nearprimitive.CryptoHash{}, nearprimitive.CryptoHash{}, []byte{}, fmt.Errorf("Failed to get next block hash: %s", err)
 	}
 
-	// TODO: Might need to borsh serialize ApprovalMessage
-	// HACK: Add 0 to indicate first type of Enum
-	approval_message := []byte{0}
-	approval_message = append(approval_message, next_block_hash.AsBytes()...)
-
-	lite_height_to_bytes := make([]byte, 8)
-	binary.LittleEndian.PutUint64(lite_height_to_bytes, uint64(block_view.InnerLite.Height)+2)
-	approval_message = append(approval_message, lite_height_to_bytes...)
+	// Only endorsements are accepted: a skip signs a bare height, which
+	// doesn't commit to this block or the next
+	approval_message, err := nearprimitive.ApprovalMessage(nearprimitive.NewEndorsement(next_block_hash), block_view.InnerLite.Height+2)
+	if err != nil {
+		return nearprimitive.CryptoHash{}, nearprimitive.CryptoHash{}, []byte{}, fmt.Errorf("Failed to build approval message: %w", err)
+	}
 
 	return current_block_hash, next_block_hash, approval_message, nil
 }
 
-func ValidateLightBlock(h nearprimitive.HostFunction, head *nearprimitive.LightClientBlockView, block_view *nearprimitive.LightClientBlockView, epoch_block_producers_map map[nearprimitive.CryptoHash][]nearprimitive.ValidatorStakeView) error {
-	_, _, approval_message, err := reconstruct_light_client_block_view_fields(h, *block_view)
+func next_bps_hash(h nearprimitive.HostFunction, block_producers []nearprimitive.ValidatorStakeView) (nearprimitive.CryptoHash, error) {
+	ser_next_bps, err := borsh.Serialize(block_producers)
 	if err != nil {
-		return fmt.Errorf("Failed to reconstruct light client block view fields: %s", err)
+		return nearprimitive.CryptoHash{}, fmt.Errorf("Failed to serialize next bps: %w", err)
+	}
+
+	return h.Sha256(ser_next_bps), nil
+}
+
+type validate_config struct {
+	workers          int
+	policy           Policy
+	clock            Clock
+	max_future_drift time.Duration
+}
+
+type ValidateOption func(*validate_config)
+
+// WithWorkers spreads signature verification across n goroutines, the host
+// must then be safe for concurrent use. The result doesn't depend on n.
+func WithWorkers(n int) ValidateOption {
+	return func(c *validate_config) {
+		c.workers = n
+	}
+}
+
+// DefaultMaxFutureDrift is how far a block's timestamp may be ahead of the
+// clock.
+const DefaultMaxFutureDrift = time.Minute
+
+// WithClock replaces time.Now as the clock block timestamps are checked
+// against.
+func WithClock(clock Clock) ValidateOption {
+	return func(c *validate_config) {
+		c.clock = clock
 	}
+}
 
+// WithMaxFutureDrift overrides DefaultMaxFutureDrift.
+func WithMaxFutureDrift(max_drift time.Duration) ValidateOption {
+	return func(c *validate_config) {
+		c.max_future_drift = max_drift
+	}
+}
+
+func new_validate_config(opts []ValidateOption) validate_config {
+	config := validate_config{
+		workers:          1,
+		clock:            time.Now,
+		max_future_drift: DefaultMaxFutureDrift,
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return config
+}
+
+func ValidateLightBlock(h nearprimitive.HostFunction, head *nearprimitive.LightClientBlockView, block_view *nearprimitive.LightClientBlockView, epoch_block_producers_map map[nearprimitive.CryptoHash][]nearprimitive.ValidatorStakeView, opts ...ValidateOption) error {
+	_, err := ValidateLightBlockDetailed(h, head, block_view, epoch_block_producers_map, opts...)
+	return err
+}
+
+// ValidateLightBlockDetailed validates block_view like ValidateLightBlock and
+// reports how it was approved. Blocks that don't fit the head are rejected
+// with an empty report before any signature is checked; otherwise every
+// approval is checked and the error is about the first problem found.
+func ValidateLightBlockDetailed(h nearprimitive.HostFunction, head *nearprimitive.LightClientBlockView, block_view *nearprimitive.LightClientBlockView, epoch_block_producers_map map[nearprimitive.CryptoHash][]nearprimitive.ValidatorStakeView, opts ...ValidateOption) (ValidationReport, error) {
+	return validate_light_block(h, head, block_view, BlockProducersMap(epoch_block_producers_map), new_validate_config(opts))
+}
+
+func validate_light_block(h nearprimitive.HostFunction, head *nearprimitive.LightClientBlockView, block_view *nearprimitive.LightClientBlockView, epoch_block_producers EpochBlockProducers, config validate_config) (ValidationReport, error) {
+	// Cheap checks first, signatures are only verified for plausible blocks
+	err := check_block_against_head(head, block_view, config)
+	if err != nil {
+		return ValidationReport{Approvals: []ValidatorApproval{}}, err
+	}
+
+	report, _, err := inspect_light_block(h, block_view, epoch_block_producers, config)
+	if err != nil {
+		return report, err
+	}
+
+	return report, check_policy(config, head, block_view, report)
+}
+
+func check_block_against_head(head *nearprimitive.LightClientBlockView, block_view *nearprimitive.LightClientBlockView, config validate_config) error {
 	if block_view.InnerLite.Height <= head.InnerLite.Height {
-		return fmt.Errorf("Block view height is not ahead of the head's height")
+		return fmt.Errorf("%w: %d <= %d", ErrHeightNotAhead, block_view.InnerLite.Height, head.InnerLite.Height)
+	}
+
+	if block_view.InnerLite.Timestamp <= head.InnerLite.Timestamp {
+		return fmt.Errorf("%w: %d <= %d", ErrTimestampNotAhead, block_view.InnerLite.Timestamp, head.InnerLite.Timestamp)
+	}
+
+	// Too far in the future for a time.Time, would wrap around to the past
+	if block_view.InnerLite.Timestamp > math.MaxInt64 {
+		return fmt.Errorf("%w: %d overflows", ErrTimestampInFuture, block_view.InnerLite.Timestamp)
+	}
+
+	now := config.clock()
+	if block_time(block_view).Sub(now) > config.max_future_drift {
+		return fmt.Errorf("%w: %s is more than %s after %s", ErrTimestampInFuture, block_time(block_view).UTC().Format(time.RFC3339Nano), config.max_future_drift, now.UTC().Format(time.RFC3339Nano))
 	}
 
 	if !(block_view.InnerLite.EpochId == head.InnerLite.EpochId || block_view.InnerLite.EpochId == head.InnerLite.NextEpochId) {
-		return fmt.Errorf("Block view epoch id not present in the head %v %v %v", block_view.InnerLite.EpochId, head.InnerLite.EpochId, head.InnerLite.NextEpochId)
+		return fmt.Errorf("%w: block view epoch id not present in the head %v %v %v", ErrUnknownEpoch, block_view.InnerLite.EpochId, head.InnerLite.EpochId, head.InnerLite.NextEpochId)
 	}
 
 	if block_view.InnerLite.EpochId == head.InnerLite.NextEpochId && len(block_view.NextBps) == 0 {
-		return fmt.Errorf("Block view epoch id is not the next epoch")
+		return fmt.Errorf("%w: block view starts epoch %v", ErrMissingNextBps, block_view.InnerLite.EpochId)
+	}
+
+	return nil
+}
+
+// verified_block holds what verify_light_block learned about a block whose
+// approvals reach the finality threshold.
+type verified_block struct {
+	block_hash       nearprimitive.CryptoHash
+	approval_message []byte
+	block_producers  []nearprimitive.ValidatorStakeView
+	// Indices of the block producers that signed
+	signers []int
+	report  ValidationReport
+}
+
+// verify_light_block checks everything that doesn't depend on the head: the
+// approvals of the block's epoch producers and the hash of its next bps.
+func verify_light_block(h nearprimitive.HostFunction, block_view *nearprimitive.LightClientBlockView, epoch_block_producers EpochBlockProducers, config validate_config) (verified_block, error) {
+	report, bps, err := inspect_light_block(h, block_view, epoch_block_producers, config)
+	if err != nil {
+		return verified_block{}, err
 	}
 
-	total_stake := num.U128{}
-	approved_stake := num.U128{}
+	signers := []int{}
+	for i, approval := range report.Approvals {
+		if approval.Status == ApprovalSigned {
+			signers = append(signers, i)
+		}
+	}
+
+	return verified_block{
+		block_hash:       report.CurrentBlockHash,
+		approval_message: report.ApprovalMessage,
+		block_producers:  bps,
+		signers:          signers,
+		report:           report,
+	}, nil
+}
+
+// inspect_light_block does the work of verify_light_block, filling the report
+// as it goes.
+func inspect_light_block(h nearprimitive.HostFunction, block_view *nearprimitive.LightClientBlockView, epoch_block_producers EpochBlockProducers, config validate_config) (ValidationReport, []nearprimitive.ValidatorStakeView, error) {
+	report := ValidationReport{
+		Approvals:      []ValidatorApproval{},
+		NextBpsPresent: len(block_view.NextBps) > 0,
+	}
 
-	epoch_block_producers := epoch_block_producers_map[block_view.InnerLite.EpochId]
+	current_block_hash, next_block_hash, approval_message, err := reconstruct_light_client_block_view_fields(h, *block_view)
+	if err != nil {
+		return report, nil, fmt.Errorf("Failed to reconstruct light client block view fields: %w", err)
+	}
 
-	for i, signature := range block_view.ApprovalsAfterNext {
-		block_producer := epoch_block_producers[i]
-		bp_stake_view, err := block_producer.GetValidatorStake()
+	report.CurrentBlockHash = current_block_hash
+	report.NextBlockHash = next_block_hash
+	report.ApprovalMessage = approval_message
 
+	if report.NextBpsPresent {
+		next_bps_hash, err := next_bps_hash(h, block_view.NextBps)
 		if err != nil {
-			return fmt.Errorf("Failed to retrieve validator stake %v", i)
+			return report, nil, fmt.Errorf("Failed to hash block view next bps: %w", err)
 		}
 
-		bp_stake := bp_stake_view.Stake
-		total_stake = total_stake.Add(bp_stake)
+		report.NextBpHashMatches = next_bps_hash == block_view.InnerLite.NextBpHash
+	}
 
-		if signature == nil {
-			continue
+	bps, err := epoch_block_producers.BlockProducers(block_view.InnerLite.EpochId)
+	if err != nil {
+		return report, nil, fmt.Errorf("Failed to get epoch block producers: %w", err)
+	}
+
+	report.TotalStake, err = epoch_block_producers.TotalStake(block_view.InnerLite.EpochId)
+	if err != nil {
+		return report, nil, fmt.Errorf("Failed to get epoch total stake: %w", err)
+	}
+
+	// Approvals are untrusted input, every producer needs a slot. Like
+	// nearcore, approvals past the last producer are ignored
+	if len(block_view.ApprovalsAfterNext) < len(bps) {
+		return report, bps, fmt.Errorf("%w: %d approvals for %d block producers of epoch %v", ErrApprovalCountMismatch, len(block_view.ApprovalsAfterNext), len(bps), block_view.InnerLite.EpochId)
+	}
+
+	// Signatures are verified at once, so that batching hosts can amortize
+	signed := []int{}
+	msgs := [][]byte{}
+	sigs := []nearprimitive.Signature{}
+	keys := []nearprimitive.PublicKey{}
+
+	for i, signature := range block_view.ApprovalsAfterNext[:len(bps)] {
+		bp_stake_view, err := bps[i].GetValidatorStake()
+		if err != nil {
+			return report, bps, fmt.Errorf("Failed to retrieve validator stake %v: %w", i, err)
 		}
 
-		approved_stake = approved_stake.Add(bp_stake)
+		report.Approvals = append(report.Approvals, ValidatorApproval{
+			AccountId: bp_stake_view.AccountId,
+			PublicKey: bp_stake_view.PublicKey,
+			Stake:     bp_stake_view.Stake.U128(),
+			Status:    ApprovalMissing,
+		})
+
+		if signature != nil {
+			signed = append(signed, i)
+			msgs = append(msgs, approval_message)
+			sigs = append(sigs, *signature)
+			keys = append(keys, bp_stake_view.PublicKey)
+		}
+	}
+
+	var signature_err error
+
+	for j, valid := range verify_signatures(h, msgs, sigs, keys, config.workers) {
+		approval := &report.Approvals[signed[j]]
+
+		if valid {
+			approval.Status = ApprovalSigned
+			report.ApprovedStake = report.ApprovedStake.Add(approval.Stake)
+			continue
+		}
 
-		validator_pub_key := bp_stake_view.PublicKey
-		if !signature.Verify(approval_message, &validator_pub_key) {
-			return fmt.Errorf("Failed to verify the signature for %s", approval_message)
+		approval.Status = ApprovalInvalid
+		if signature_err == nil {
+			signature_err = &InvalidSignatureError{Index: signed[j], AccountId: approval.AccountId}
 		}
 	}
 
-	threshold := total_stake.Mul64(2).Quo64(3)
-	if approved_stake.LessOrEqualTo(threshold) {
-		return fmt.Errorf("Block is not final: stake threshold is not reached")
+	if signature_err != nil {
+		return report, bps, signature_err
 	}
 
-	// HACK
-	next_bps := []nearprimitive.ValidatorStakeView{}
+	threshold := report.TotalStake.Mul64(2).Quo64(3)
+	if report.ApprovedStake.LessOrEqualTo(threshold) {
+		return report, bps, &InsufficientStakeError{ApprovedStake: report.ApprovedStake, TotalStake: report.TotalStake}
+	}
 
-	for _, bps := range block_view.NextBps {
-		stake := bps.V1.Stake
-		stake_lo := stake.AsUint64()
-		stake_hi := stake.Rsh(64).AsUint64()
-		bps.V1.Stake = num.U128FromRaw(stake_lo, stake_hi)
+	if report.NextBpsPresent && !report.NextBpHashMatches {
+		return report, bps, fmt.Errorf("%w: expected %v", ErrNextBpHashMismatch, block_view.InnerLite.NextBpHash)
+	}
 
-		next_bps = append(next_bps, bps)
+	return report, bps, nil
+}
+
+// verify_signatures splits the signatures into one contiguous chunk per
+// worker. Results keep their index, so callers see the same outcome whatever
+// the scheduling.
+func verify_signatures(h nearprimitive.HostFunction, msgs [][]byte, sigs []nearprimitive.Signature, keys []nearprimitive.PublicKey, workers int) []bool {
+	if workers > len(sigs) {
+		workers = len(sigs)
 	}
 
-	if len(block_view.NextBps) > 0 {
-		ser_block_view_next_bps, err := borsh.Serialize(next_bps)
-		if err != nil {
-			return fmt.Errorf("Failed to serialize block view next bps")
-		}
+	if workers <= 1 {
+		return nearprimitive.VerifyBatch(h, msgs, sigs, keys)
+	}
+
+	res := make([]bool, len(sigs))
+	chunk_size := (len(sigs) + workers - 1) / workers
 
-		next_bps_hash := h.Sha256(ser_block_view_next_bps)
-		if next_bps_hash != block_view.InnerLite.NextBpHash {
-			return fmt.Errorf("Incorrect next bp hash in block view")
+	var wg sync.WaitGroup
+	for start := 0; start < len(sigs); start += chunk_size {
+		end := start + chunk_size
+		if end > len(sigs) {
+			end = len(sigs)
 		}
+
+		wg.Add(1)
+		go func(start int, end int) {
+			defer wg.Done()
+			copy(res[start:end], nearprimitive.VerifyBatch(h, msgs[start:end], sigs[start:end], keys[start:end]))
+		}(start, end)
 	}
+	wg.Wait()
 
-	return nil
+	return res
 }
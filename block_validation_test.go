@@ -3,6 +3,7 @@
 package light
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/electron-labs/near-light-client-go/mock"
@@ -1567,3 +1568,39 @@ func TestValidateLightBlock(t *testing.T) {
 		t.Errorf("Block verification succeded but it should not!!!")
 	}
 }
+
+func TestValidateLightBlockChecksHeadFirst(t *testing.T) {
+	pre_epoch, err := GetClientBlockView(CLIENT_RESPONSE_PREVIOUS_EPOCH)
+	if err != nil {
+		t.Fatalf("Failed to parse prev client block: %s", err)
+	}
+
+	curr_epoch, err := GetClientBlockView(CLIENT_BLOCK_RESPONSE)
+	if err != nil {
+		t.Fatalf("Failed to parse current client block: %s", err)
+	}
+
+	lc := &DummyLiteClient{}
+	lc.new_from_checkpoint(pre_epoch)
+
+	h := &counting_host{}
+
+	// Not ahead of the head, no signature is worth checking
+	err = ValidateLightBlock(h, &curr_epoch, &curr_epoch, lc.BlockProducerPerEpoch)
+	if !errors.Is(err, ErrHeightNotAhead) {
+		t.Errorf("Expected ErrHeightNotAhead, got %v", err)
+	}
+
+	if h.verifies != 0 {
+		t.Errorf("Verified %d signatures of a stale block", h.verifies)
+	}
+
+	err = ValidateLightBlock(h, &lc.Head, &curr_epoch, lc.BlockProducerPerEpoch)
+	if err != nil {
+		t.Errorf("Failed to validate block: %s", err)
+	}
+
+	if h.verifies == 0 {
+		t.Errorf("No signature was verified")
+	}
+}
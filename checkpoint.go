@@ -0,0 +1,86 @@
+// Copyright © 2022, Electron Labs
+
+package light
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	base58 "github.com/btcsuite/btcutil/base58"
+	"github.com/electron-labs/near-light-client-go/nearprimitive"
+)
+
+// Checkpoint is the trusted starting point of a light client: a light client
+// block, in the shape returned by next_light_client_block, pinned to the
+// base58 hash the operator expects it to have.
+type Checkpoint struct {
+	BlockHash        string `json:"block_hash"`
+	LightClientBlock Result `json:"light_client_block"`
+}
+
+// VerifyCheckpoint recomputes the hash of the checkpoint block and checks it
+// against the trusted hash.
+func VerifyCheckpoint(h nearprimitive.HostFunction, checkpoint nearprimitive.LightClientBlockView, trusted_block_hash nearprimitive.CryptoHash) error {
+	block_hash, err := checkpoint.CurrentBlockHash(h)
+	if err != nil {
+		return fmt.Errorf("Failed to get checkpoint block hash: %w", err)
+	}
+
+	if block_hash != trusted_block_hash {
+		return fmt.Errorf("Checkpoint block hash mismatch: expected %s, got %s", base58.Encode(trusted_block_hash[:]), base58.Encode(block_hash[:]))
+	}
+
+	return nil
+}
+
+// ParseCheckpoint parses a json encoded Checkpoint and only returns its block
+// if it hashes to the pinned block hash.
+func ParseCheckpoint(h nearprimitive.HostFunction, data []byte) (nearprimitive.LightClientBlockView, error) {
+	var cp Checkpoint
+
+	err := json.Unmarshal(data, &cp)
+	if err != nil {
+		return nearprimitive.LightClientBlockView{}, fmt.Errorf("Failed to parse checkpoint: %w", err)
+	}
+
+	trusted_block_hash := nearprimitive.CryptoHash{}
+	err = trusted_block_hash.TryFromRaw(base58.Decode(cp.BlockHash))
+	if err != nil {
+		return nearprimitive.LightClientBlockView{}, fmt.Errorf("Failed to decode checkpoint block hash: %w", err)
+	}
+
+	block_view := NearLightClientBlockView{Result: cp.LightClientBlock}
+	checkpoint, err := block_view.parse()
+	if err != nil {
+		return nearprimitive.LightClientBlockView{}, fmt.Errorf("Failed to parse checkpoint block: %w", err)
+	}
+
+	err = VerifyCheckpoint(h, checkpoint, trusted_block_hash)
+	if err != nil {
+		return nearprimitive.LightClientBlockView{}, err
+	}
+
+	return checkpoint, nil
+}
+
+// LoadCheckpoint reads and verifies a checkpoint file.
+func LoadCheckpoint(h nearprimitive.HostFunction, path string) (nearprimitive.LightClientBlockView, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nearprimitive.LightClientBlockView{}, fmt.Errorf("Failed to read checkpoint file: %w", err)
+	}
+
+	return ParseCheckpoint(h, data)
+}
+
+// BootstrapFromCheckpointFile bootstraps the client from a verified checkpoint
+// file and refuses to start if the checkpoint doesn't match its pinned hash.
+func (lc *LightClient) BootstrapFromCheckpointFile(path string, heights_to_track uint64) error {
+	checkpoint, err := LoadCheckpoint(lc.host, path)
+	if err != nil {
+		return fmt.Errorf("Failed to load checkpoint: %w", err)
+	}
+
+	return lc.Bootstrap(checkpoint, heights_to_track)
+}
@@ -0,0 +1,84 @@
+// Copyright © 2022, Electron Labs
+
+package light
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/electron-labs/near-light-client-go/mock"
+)
+
+const CHECKPOINT_BLOCK_HASH = "853G4n846XdQNpYwM6Y2LwSWExro91Bkbf3dHdaRV3g7"
+
+func write_checkpoint_file(t *testing.T, block_hash string) string {
+	var rpc_response NearLightClientBlockView
+	err := json.Unmarshal([]byte(CLIENT_RESPONSE_PREVIOUS_EPOCH), &rpc_response)
+	if err != nil {
+		t.Fatalf("Failed to parse client block: %s", err)
+	}
+
+	data, err := json.Marshal(Checkpoint{BlockHash: block_hash, LightClientBlock: rpc_response.Result})
+	if err != nil {
+		t.Fatalf("Failed to serialize checkpoint: %s", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	err = os.WriteFile(path, data, 0o600)
+	if err != nil {
+		t.Fatalf("Failed to write checkpoint: %s", err)
+	}
+
+	return path
+}
+
+func TestLoadCheckpoint(t *testing.T) {
+	path := write_checkpoint_file(t, CHECKPOINT_BLOCK_HASH)
+
+	checkpoint, err := LoadCheckpoint(mock.MockHostFunction{}, path)
+	if err != nil {
+		t.Errorf("Failed to load checkpoint: %s", err)
+	}
+
+	if checkpoint.InnerLite.Height != 86441383 {
+		t.Errorf("Unexpected checkpoint height: %d", checkpoint.InnerLite.Height)
+	}
+
+	lc := NewLightClient(mock.MockHostFunction{})
+	err = lc.BootstrapFromCheckpointFile(path, 10)
+	if err != nil {
+		t.Errorf("Failed to bootstrap from checkpoint: %s", err)
+	}
+
+	curr_epoch, err := GetClientBlockView(CLIENT_BLOCK_RESPONSE)
+	if err != nil {
+		t.Errorf("Failed to parse current client block: %s", err)
+	}
+
+	err = lc.ValidateAndUpdateHead(curr_epoch)
+	if err != nil {
+		t.Errorf("Failed to validate block: %s", err)
+	}
+}
+
+func TestLoadCheckpointHashMismatch(t *testing.T) {
+	// hash of CLIENT_BLOCK_RESPONSE's previous block
+	path := write_checkpoint_file(t, "9aHDvg6TV44qRSoaiYR98ZxaQNufs7vQXV6w6Jpy5oe9")
+
+	_, err := LoadCheckpoint(mock.MockHostFunction{}, path)
+	if err == nil {
+		t.Errorf("Loaded a checkpoint that doesn't match its pinned hash")
+	}
+
+	lc := NewLightClient(mock.MockHostFunction{})
+	err = lc.BootstrapFromCheckpointFile(path, 10)
+	if err == nil {
+		t.Errorf("Bootstrapped from a checkpoint that doesn't match its pinned hash")
+	}
+
+	if lc.CurrentBlockHeight() != 0 {
+		t.Errorf("Client started despite the hash mismatch")
+	}
+}
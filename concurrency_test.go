@@ -0,0 +1,220 @@
+// Copyright © 2022, Electron Labs
+
+package light
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/electron-labs/near-light-client-go/mock"
+	"github.com/electron-labs/near-light-client-go/nearprimitive"
+)
+
+// Run with -race
+
+func TestLightClientConcurrentSync(t *testing.T) {
+	h := mock.MockHostFunction{}
+	validators := new_synthetic_validators(t, "bp", 4)
+	next_validators := new_synthetic_stake_views(t, "next", 4)
+
+	lc := new_synthetic_client(t, h, validators, WithStore(NewMemoryStore()))
+
+	blocks := []nearprimitive.LightClientBlockView{}
+	for height := nearprimitive.BlockHeight(101); height <= 140; height++ {
+		block_view := new_synthetic_block(t, h, height, synthetic_epoch_1, synthetic_epoch_2, next_validators, 0)
+		sign_synthetic_block(t, h, &block_view, validators, all_sign)
+		blocks = append(blocks, block_view)
+	}
+
+	var heights []nearprimitive.BlockHeight
+	lc.Subscribe(func(e Event) {
+		if new_head, ok := e.(NewHeadEvent); ok {
+			heights = append(heights, new_head.Head.InnerLite.Height)
+		}
+	})
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				head_hash, head := lc.HeadSnapshot()
+				expected_hash, err := head.CurrentBlockHash(h)
+				if err != nil {
+					t.Errorf("Failed to hash head: %s", err)
+					return
+				}
+
+				if head_hash != expected_hash {
+					t.Errorf("Inconsistent head snapshot at height %d", head.InnerLite.Height)
+					return
+				}
+
+				lc.HeadByHeight(head.InnerLite.Height)
+				lc.State()
+				_, _ = lc.EpochBlockProducers(synthetic_epoch_1)
+			}
+		}()
+	}
+
+	for _, block_view := range blocks {
+		err := lc.ValidateAndUpdateHead(block_view)
+		if err != nil {
+			t.Errorf("Failed to validate block: %s", err)
+		}
+	}
+
+	close(done)
+	wg.Wait()
+
+	if lc.CurrentBlockHeight() != 140 {
+		t.Errorf("Unexpected head height %d", lc.CurrentBlockHeight())
+	}
+
+	if len(heights) != len(blocks) {
+		t.Fatalf("Expected %d new heads, got %d", len(blocks), len(heights))
+	}
+
+	for i, height := range heights {
+		if height != blocks[i].InnerLite.Height {
+			t.Errorf("Event %d out of order: height %d", i, height)
+		}
+	}
+}
+
+func TestLightClientConcurrentProofVerification(t *testing.T) {
+	head, err := GetClientBlockView(LIGHT_CLIENT_BLOCK)
+	if err != nil {
+		t.Fatalf("Failed to parse light client block: %s", err)
+	}
+
+	proof, err := GetNearTxResult(EXECUTION_OUTCOME)
+	if err != nil {
+		t.Fatalf("Failed to parse proof: %s", err)
+	}
+
+	pre_epoch, err := GetClientBlockView(CLIENT_RESPONSE_PREVIOUS_EPOCH)
+	if err != nil {
+		t.Fatalf("Failed to parse prev client block: %s", err)
+	}
+
+	h := mock.MockHostFunction{}
+	lc := NewLightClient(h)
+	err = lc.Bootstrap(head, 4)
+	if err != nil {
+		t.Fatalf("Failed to bootstrap: %s", err)
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 10; j++ {
+				head_hash, _ := lc.HeadSnapshot()
+
+				_, err := lc.VerifyTransactionProof(head_hash, proof)
+				if err != nil {
+					t.Errorf("Failed to verify proof: %s", err)
+					return
+				}
+			}
+		}()
+	}
+
+	// Meanwhile keep updating the client: rejected blocks and re-bootstraps
+	// from the same checkpoint must not disturb the verifiers.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for j := 0; j < 10; j++ {
+			if lc.ValidateAndUpdateHead(pre_epoch) == nil {
+				t.Errorf("Block verification succeded but it should not!!!")
+			}
+
+			err := lc.Bootstrap(head, 4)
+			if err != nil {
+				t.Errorf("Failed to bootstrap: %s", err)
+			}
+		}
+	}()
+
+	wg.Wait()
+}
+
+// slow_host is MockHostFunction whose signature checks wait for release.
+type slow_host struct {
+	mock.MockHostFunction
+	started chan struct{}
+	release chan struct{}
+	once    *sync.Once
+}
+
+func (s slow_host) Verify(sig nearprimitive.Signature, data []byte, public_key nearprimitive.PublicKey) bool {
+	s.once.Do(func() { close(s.started) })
+	<-s.release
+	return s.MockHostFunction.Verify(sig, data, public_key)
+}
+
+func TestLightClientReadersDuringSlowVerify(t *testing.T) {
+	h := slow_host{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+		once:    &sync.Once{},
+	}
+	validators := new_synthetic_validators(t, "bp", 4)
+	next_validators := new_synthetic_stake_views(t, "next", 4)
+
+	lc := new_synthetic_client(t, h, validators)
+	head_hash, _ := lc.HeadSnapshot()
+
+	block_view := new_synthetic_block(t, h, 101, synthetic_epoch_1, synthetic_epoch_2, next_validators, 0)
+	sign_synthetic_block(t, h, &block_view, validators, all_sign)
+
+	updated := make(chan error)
+	go func() {
+		updated <- lc.ValidateAndUpdateHead(block_view)
+	}()
+
+	<-h.started
+
+	read := make(chan struct{})
+	go func() {
+		lc.HeadSnapshot()
+		lc.HeadByHash(head_hash)
+		lc.CurrentBlockHeight()
+		_, _ = lc.EpochBlockProducers(synthetic_epoch_1)
+		close(read)
+	}()
+
+	select {
+	case <-read:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Readers blocked while approvals were verified")
+	}
+
+	close(h.release)
+
+	err := <-updated
+	if err != nil {
+		t.Fatalf("Failed to validate block: %s", err)
+	}
+
+	if lc.CurrentBlockHeight() != 101 {
+		t.Errorf("Unexpected head height %d", lc.CurrentBlockHeight())
+	}
+}
@@ -0,0 +1,268 @@
+// Copyright © 2022, Electron Labs
+
+package light
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/electron-labs/near-light-client-go/nearprimitive"
+)
+
+// ErrConflictDetected is returned by a ConflictTracker, and every client built
+// on it, once equivocation has been observed.
+var ErrConflictDetected = errors.New("conflicting light client blocks detected")
+
+type ConflictKind uint8
+
+const (
+	// Two distinct blocks at the same height
+	ConflictSameHeight ConflictKind = iota
+	// Two blocks of the same epoch announcing different next block producers
+	ConflictSameEpoch
+)
+
+func (k ConflictKind) String() string {
+	switch k {
+	case ConflictSameHeight:
+		return "same height"
+	case ConflictSameEpoch:
+		return "same epoch"
+	}
+
+	return fmt.Sprintf("ConflictKind(%d)", uint8(k))
+}
+
+type ConflictingBlock struct {
+	BlockHash       nearprimitive.CryptoHash
+	Block           nearprimitive.LightClientBlockView
+	ApprovalMessage []byte
+}
+
+// DoubleSigner is a block producer whose approvals are on both conflicting
+// blocks.
+type DoubleSigner struct {
+	AccountId nearprimitive.AccountId
+	PublicKey nearprimitive.PublicKey
+	// Index of the producer in First.Block's epoch, and in Second.Block's
+	SignerIndices [2]int
+}
+
+// EquivocationEvidence proves that block producers approved two conflicting
+// blocks, both of which reach the finality threshold on their own.
+type EquivocationEvidence struct {
+	Kind          ConflictKind
+	First         ConflictingBlock
+	Second        ConflictingBlock
+	DoubleSigners []DoubleSigner
+}
+
+// ConflictError carries the evidence behind ErrConflictDetected.
+type ConflictError struct {
+	Evidence *EquivocationEvidence
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s: %s conflict at height %d, %d double signers", ErrConflictDetected, e.Evidence.Kind, e.Evidence.Second.Block.InnerLite.Height, len(e.Evidence.DoubleSigners))
+}
+
+func (e *ConflictError) Unwrap() error {
+	return ErrConflictDetected
+}
+
+type observed_block struct {
+	block    ConflictingBlock
+	verified verified_block
+}
+
+// DefaultConflictWindow is the number of recently observed blocks a
+// ConflictTracker compares new blocks against.
+const DefaultConflictWindow = 128
+
+// ConflictTracker wraps ValidateLightBlock and remembers the blocks it has
+// seen reach finality. Once two of them conflict while sharing signers, it
+// keeps the evidence and refuses every further block.
+type ConflictTracker struct {
+	window    int
+	order     []nearprimitive.CryptoHash
+	observed  map[nearprimitive.CryptoHash]observed_block
+	by_height map[nearprimitive.BlockHeight][]nearprimitive.CryptoHash
+	// Blocks that announced next block producers, by their epoch
+	by_epoch map[nearprimitive.CryptoHash][]nearprimitive.CryptoHash
+	evidence *EquivocationEvidence
+}
+
+func NewConflictTracker(window int) *ConflictTracker {
+	if window <= 0 {
+		window = 1
+	}
+
+	return &ConflictTracker{
+		window:    window,
+		observed:  map[nearprimitive.CryptoHash]observed_block{},
+		by_height: map[nearprimitive.BlockHeight][]nearprimitive.CryptoHash{},
+		by_epoch:  map[nearprimitive.CryptoHash][]nearprimitive.CryptoHash{},
+	}
+}
+
+// Evidence returns the detected conflict, if any.
+func (ct *ConflictTracker) Evidence() *EquivocationEvidence {
+	return ct.evidence
+}
+
+// Validate validates block_view against head like ValidateLightBlock. Blocks
+// whose approvals are valid are remembered even if they are not ahead of the
+// head, so that a competing block at an already accepted height is caught.
+func (ct *ConflictTracker) Validate(h nearprimitive.HostFunction, head *nearprimitive.LightClientBlockView, block_view *nearprimitive.LightClientBlockView, epoch_block_producers EpochBlockProducers, opts ...ValidateOption) error {
+	if ct.evidence != nil {
+		return &ConflictError{Evidence: ct.evidence}
+	}
+
+	config := new_validate_config(opts)
+
+	verified, err := verify_light_block(h, block_view, epoch_block_producers, config)
+	if err != nil {
+		return err
+	}
+
+	evidence := ct.observe(*block_view, verified)
+	if evidence != nil {
+		ct.evidence = evidence
+		return &ConflictError{Evidence: evidence}
+	}
+
+	err = check_block_against_head(head, block_view, config)
+	if err != nil {
+		return err
+	}
+
+	return check_policy(config, head, block_view, verified.report)
+}
+
+func (ct *ConflictTracker) observe(block_view nearprimitive.LightClientBlockView, verified verified_block) *EquivocationEvidence {
+	if _, ok := ct.observed[verified.block_hash]; ok {
+		return nil
+	}
+
+	current := observed_block{
+		block: ConflictingBlock{
+			BlockHash:       verified.block_hash,
+			Block:           block_view,
+			ApprovalMessage: verified.approval_message,
+		},
+		verified: verified,
+	}
+
+	for _, other_hash := range ct.by_height[block_view.InnerLite.Height] {
+		evidence := conflict_evidence(ConflictSameHeight, ct.observed[other_hash], current)
+		if evidence != nil {
+			return evidence
+		}
+	}
+
+	if len(block_view.NextBps) > 0 {
+		for _, other_hash := range ct.by_epoch[block_view.InnerLite.EpochId] {
+			other := ct.observed[other_hash]
+			if other.block.Block.InnerLite.NextBpHash == block_view.InnerLite.NextBpHash {
+				continue
+			}
+
+			evidence := conflict_evidence(ConflictSameEpoch, other, current)
+			if evidence != nil {
+				return evidence
+			}
+		}
+	}
+
+	ct.record(current)
+
+	return nil
+}
+
+func (ct *ConflictTracker) record(ob observed_block) {
+	block_hash := ob.block.BlockHash
+	inner_lite := ob.block.Block.InnerLite
+
+	ct.order = append(ct.order, block_hash)
+	ct.observed[block_hash] = ob
+	ct.by_height[inner_lite.Height] = append(ct.by_height[inner_lite.Height], block_hash)
+	if len(ob.block.Block.NextBps) > 0 {
+		ct.by_epoch[inner_lite.EpochId] = append(ct.by_epoch[inner_lite.EpochId], block_hash)
+	}
+
+	for len(ct.order) > ct.window {
+		ct.evict_oldest()
+	}
+}
+
+func remove_hash(hashes []nearprimitive.CryptoHash, block_hash nearprimitive.CryptoHash) []nearprimitive.CryptoHash {
+	res := []nearprimitive.CryptoHash{}
+	for _, hash := range hashes {
+		if hash != block_hash {
+			res = append(res, hash)
+		}
+	}
+
+	return res
+}
+
+func (ct *ConflictTracker) evict_oldest() {
+	oldest := ct.order[0]
+	ct.order = ct.order[1:]
+
+	inner_lite := ct.observed[oldest].block.Block.InnerLite
+	delete(ct.observed, oldest)
+
+	ct.by_height[inner_lite.Height] = remove_hash(ct.by_height[inner_lite.Height], oldest)
+	if len(ct.by_height[inner_lite.Height]) == 0 {
+		delete(ct.by_height, inner_lite.Height)
+	}
+
+	if epoch_blocks, ok := ct.by_epoch[inner_lite.EpochId]; ok {
+		ct.by_epoch[inner_lite.EpochId] = remove_hash(epoch_blocks, oldest)
+		if len(ct.by_epoch[inner_lite.EpochId]) == 0 {
+			delete(ct.by_epoch, inner_lite.EpochId)
+		}
+	}
+}
+
+// conflict_evidence returns nil unless some block producer signed both
+// blocks.
+func conflict_evidence(kind ConflictKind, first observed_block, second observed_block) *EquivocationEvidence {
+	first_signers := map[nearprimitive.PublicKey]int{}
+	for _, i := range first.verified.signers {
+		bp, err := first.verified.block_producers[i].GetValidatorStake()
+		if err != nil {
+			continue
+		}
+
+		first_signers[bp.PublicKey] = i
+	}
+
+	double_signers := []DoubleSigner{}
+	for _, i := range second.verified.signers {
+		bp, err := second.verified.block_producers[i].GetValidatorStake()
+		if err != nil {
+			continue
+		}
+
+		if first_index, ok := first_signers[bp.PublicKey]; ok {
+			double_signers = append(double_signers, DoubleSigner{
+				AccountId:     bp.AccountId,
+				PublicKey:     bp.PublicKey,
+				SignerIndices: [2]int{first_index, i},
+			})
+		}
+	}
+
+	if len(double_signers) == 0 {
+		return nil
+	}
+
+	return &EquivocationEvidence{
+		Kind:          kind,
+		First:         first.block,
+		Second:        second.block,
+		DoubleSigners: double_signers,
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright © 2022, Electron Labs
+
+package light
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/electron-labs/near-light-client-go/mock"
+)
+
+func TestConflictSameHeight(t *testing.T) {
+	h := mock.MockHostFunction{}
+	validators := new_synthetic_validators(t, "bp", 4)
+	next_validators := new_synthetic_stake_views(t, "next", 4)
+
+	lc := new_synthetic_client(t, h, validators)
+
+	first := new_synthetic_block(t, h, 110, synthetic_epoch_1, synthetic_epoch_2, next_validators, 1)
+	sign_synthetic_block(t, h, &first, validators, all_sign)
+
+	second := new_synthetic_block(t, h, 110, synthetic_epoch_1, synthetic_epoch_2, next_validators, 2)
+	sign_synthetic_block(t, h, &second, validators, func(i int) bool { return i != 0 })
+
+	err := lc.ValidateAndUpdateHead(first)
+	if err != nil {
+		t.Fatalf("Failed to validate block: %s", err)
+	}
+
+	err = lc.ValidateAndUpdateHead(second)
+	if !errors.Is(err, ErrConflictDetected) {
+		t.Fatalf("Expected a conflict, got %v", err)
+	}
+
+	var conflict_err *ConflictError
+	if !errors.As(err, &conflict_err) {
+		t.Fatalf("Expected a ConflictError, got %v", err)
+	}
+
+	evidence := conflict_err.Evidence
+	if evidence.Kind != ConflictSameHeight {
+		t.Errorf("Unexpected conflict kind: %s", evidence.Kind)
+	}
+
+	if evidence.First.Block.InnerRestHash != first.InnerRestHash || evidence.Second.Block.InnerRestHash != second.InnerRestHash {
+		t.Errorf("Evidence doesn't hold both blocks")
+	}
+
+	if len(evidence.First.ApprovalMessage) == 0 || string(evidence.First.ApprovalMessage) == string(evidence.Second.ApprovalMessage) {
+		t.Errorf("Evidence doesn't hold both approval messages")
+	}
+
+	if len(evidence.DoubleSigners) != 3 {
+		t.Errorf("Expected 3 double signers, got %d", len(evidence.DoubleSigners))
+	}
+
+	for _, ds := range evidence.DoubleSigners {
+		if ds.AccountId == validators[0].stake_view.V1.AccountId {
+			t.Errorf("%s did not sign both blocks", ds.AccountId)
+		}
+	}
+
+	if lc.Evidence() != evidence {
+		t.Errorf("Client doesn't expose the evidence")
+	}
+
+	// Every further update is refused
+	third := new_synthetic_block(t, h, 111, synthetic_epoch_1, synthetic_epoch_2, next_validators, 3)
+	sign_synthetic_block(t, h, &third, validators, all_sign)
+
+	err = lc.ValidateAndUpdateHead(third)
+	if !errors.Is(err, ErrConflictDetected) {
+		t.Errorf("Expected the client to stay halted, got %v", err)
+	}
+
+	if lc.CurrentBlockHeight() != 110 {
+		t.Errorf("Head moved after a conflict: %d", lc.CurrentBlockHeight())
+	}
+}
+
+func TestConflictSurvivesRestart(t *testing.T) {
+	h := mock.MockHostFunction{}
+	validators := new_synthetic_validators(t, "bp", 4)
+	next_validators := new_synthetic_stake_views(t, "next", 4)
+	store := NewMemoryStore()
+
+	lc := new_synthetic_client(t, h, validators, WithStore(store))
+
+	first := new_synthetic_block(t, h, 110, synthetic_epoch_1, synthetic_epoch_2, next_validators, 1)
+	sign_synthetic_block(t, h, &first, validators, all_sign)
+
+	second := new_synthetic_block(t, h, 110, synthetic_epoch_1, synthetic_epoch_2, next_validators, 2)
+	sign_synthetic_block(t, h, &second, validators, all_sign)
+
+	err := lc.ValidateAndUpdateHead(first)
+	if err != nil {
+		t.Fatalf("Failed to validate block: %s", err)
+	}
+
+	err = lc.ValidateAndUpdateHead(second)
+	if !errors.Is(err, ErrConflictDetected) {
+		t.Fatalf("Expected a conflict, got %v", err)
+	}
+
+	// Simulate a restart
+	restarted := NewLightClient(h, WithStore(store))
+	resumed, err := restarted.Resume()
+	if err != nil || !resumed {
+		t.Fatalf("Failed to resume: %v %s", resumed, err)
+	}
+
+	if !reflect.DeepEqual(restarted.Evidence(), lc.Evidence()) {
+		t.Errorf("Evidence was not restored: %v", restarted.Evidence())
+	}
+
+	third := new_synthetic_block(t, h, 111, synthetic_epoch_1, synthetic_epoch_2, next_validators, 3)
+	sign_synthetic_block(t, h, &third, validators, all_sign)
+
+	err = restarted.ValidateAndUpdateHead(third)
+	if !errors.Is(err, ErrConflictDetected) {
+		t.Errorf("Expected the client to stay halted, got %v", err)
+	}
+
+	if restarted.CurrentBlockHeight() != 110 {
+		t.Errorf("Head moved after a conflict: %d", restarted.CurrentBlockHeight())
+	}
+}
+
+func TestConflictSameEpoch(t *testing.T) {
+	h := mock.MockHostFunction{}
+	validators := new_synthetic_validators(t, "bp", 4)
+
+	tracker := NewConflictTracker(DefaultConflictWindow)
+	producers := BlockProducersMap{synthetic_epoch_1: synthetic_stake_views(validators)}
+	head := new_synthetic_block(t, h, 100, synthetic_epoch_0, synthetic_epoch_1, synthetic_stake_views(validators), 0)
+
+	first := new_synthetic_block(t, h, 110, synthetic_epoch_1, synthetic_epoch_2, new_synthetic_stake_views(t, "a", 4), 1)
+	sign_synthetic_block(t, h, &first, validators, all_sign)
+
+	second := new_synthetic_block(t, h, 111, synthetic_epoch_1, synthetic_epoch_2, new_synthetic_stake_views(t, "b", 4), 2)
+	sign_synthetic_block(t, h, &second, validators, all_sign)
+
+	err := tracker.Validate(h, &head, &first, producers)
+	if err != nil {
+		t.Fatalf("Failed to validate block: %s", err)
+	}
+
+	err = tracker.Validate(h, &head, &second, producers)
+	if !errors.Is(err, ErrConflictDetected) {
+		t.Fatalf("Expected a conflict, got %v", err)
+	}
+
+	if tracker.Evidence().Kind != ConflictSameEpoch || len(tracker.Evidence().DoubleSigners) != 4 {
+		t.Errorf("Unexpected evidence: %v", tracker.Evidence())
+	}
+}
+
+func TestNoConflictOnResubmission(t *testing.T) {
+	h := mock.MockHostFunction{}
+	validators := new_synthetic_validators(t, "bp", 4)
+
+	tracker := NewConflictTracker(DefaultConflictWindow)
+	producers := BlockProducersMap{synthetic_epoch_1: synthetic_stake_views(validators)}
+	head := new_synthetic_block(t, h, 100, synthetic_epoch_0, synthetic_epoch_1, synthetic_stake_views(validators), 0)
+
+	block_view := new_synthetic_block(t, h, 110, synthetic_epoch_1, synthetic_epoch_2, new_synthetic_stake_views(t, "a", 4), 1)
+	sign_synthetic_block(t, h, &block_view, validators, all_sign)
+
+	for i := 0; i < 2; i++ {
+		err := tracker.Validate(h, &head, &block_view, producers)
+		if err != nil {
+			t.Errorf("Failed to validate block: %s", err)
+		}
+	}
+
+	if tracker.Evidence() != nil {
+		t.Errorf("The same block was reported as a conflict")
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright © 2022, Electron Labs
+
+package light
+
+import (
+	"fmt"
+
+	"github.com/electron-labs/near-light-client-go/nearprimitive"
+	num "github.com/shabbyrobe/go-num"
+)
+
+// EpochBlockProducers resolves the block producers that are expected to sign
+// the blocks of an epoch, and their summed stake.
+type EpochBlockProducers interface {
+	BlockProducers(epoch_id nearprimitive.CryptoHash) ([]nearprimitive.ValidatorStakeView, error)
+	TotalStake(epoch_id nearprimitive.CryptoHash) (num.U128, error)
+}
+
+func total_stake(block_producers []nearprimitive.ValidatorStakeView) (num.U128, error) {
+	total_stake := num.U128{}
+	for i, bp := range block_producers {
+		bp_stake_view, err := bp.GetValidatorStake()
+		if err != nil {
+			return num.U128{}, fmt.Errorf("Failed to retrieve validator stake %v: %w", i, err)
+		}
+
+		total_stake = total_stake.Add(bp_stake_view.Stake.U128())
+	}
+
+	return total_stake, nil
+}
+
+// BlockProducersMap adapts a plain epoch id to block producers map to
+// EpochBlockProducers.
+type BlockProducersMap map[nearprimitive.CryptoHash][]nearprimitive.ValidatorStakeView
+
+func (m BlockProducersMap) BlockProducers(epoch_id nearprimitive.CryptoHash) ([]nearprimitive.ValidatorStakeView, error) {
+	bps, ok := m[epoch_id]
+	if !ok {
+		return nil, fmt.Errorf("%w %v", ErrUnknownEpoch, epoch_id)
+	}
+
+	return bps, nil
+}
+
+// TotalStake sums the stake of the epoch's block producers on every call.
+func (m BlockProducersMap) TotalStake(epoch_id nearprimitive.CryptoHash) (num.U128, error) {
+	bps, err := m.BlockProducers(epoch_id)
+	if err != nil {
+		return num.U128{}, err
+	}
+
+	return total_stake(bps)
+}
+
+// EpochPruningPolicy decides which epochs an EpochRegistry forgets after a
+// new epoch is inserted.
+type EpochPruningPolicy interface {
+	// EpochsToPrune receives the tracked epoch ids, oldest first, and returns
+	// the ones to drop.
+	EpochsToPrune(epoch_ids []nearprimitive.CryptoHash) []nearprimitive.CryptoHash
+}
+
+// KeepLastEpochs keeps only the given number of most recently inserted
+// epochs. Zero keeps every epoch.
+type KeepLastEpochs uint64
+
+func (k KeepLastEpochs) EpochsToPrune(epoch_ids []nearprimitive.CryptoHash) []nearprimitive.CryptoHash {
+	if k == 0 || uint64(len(epoch_ids)) <= uint64(k) {
+		return nil
+	}
+
+	return epoch_ids[:uint64(len(epoch_ids))-uint64(k)]
+}
+
+// DefaultEpochsToKeep covers the epoch of the head and the one after it.
+const DefaultEpochsToKeep = KeepLastEpochs(2)
+
+type epoch_entry struct {
+	next_bp_hash    nearprimitive.CryptoHash
+	block_producers []nearprimitive.ValidatorStakeView
+	total_stake     num.U128
+}
+
+// EpochRegistry holds the block producers of every tracked epoch. Sets are
+// only accepted when they hash to the next_bp_hash of the block that
+// introduced them.
+type EpochRegistry struct {
+	policy EpochPruningPolicy
+	order  []nearprimitive.CryptoHash
+	epochs map[nearprimitive.CryptoHash]epoch_entry
+}
+
+var _ EpochBlockProducers = (*EpochRegistry)(nil)
+
+// NewEpochRegistry creates an empty registry. A nil policy never prunes.
+func NewEpochRegistry(policy EpochPruningPolicy) *EpochRegistry {
+	return &EpochRegistry{
+		policy: policy,
+		order:  []nearprimitive.CryptoHash{},
+		epochs: map[nearprimitive.CryptoHash]epoch_entry{},
+	}
+}
+
+func (er *EpochRegistry) clone() *EpochRegistry {
+	res := NewEpochRegistry(er.policy)
+	res.order = append(res.order, er.order...)
+	for epoch_id, entry := range er.epochs {
+		res.epochs[epoch_id] = entry
+	}
+
+	return res
+}
+
+// Insert records the block producers of epoch_id after checking them against
+// next_bp_hash.
+func (er *EpochRegistry) Insert(h nearprimitive.HostFunction, epoch_id nearprimitive.CryptoHash, next_bp_hash nearprimitive.CryptoHash, block_producers []nearprimitive.ValidatorStakeView) error {
+	if len(block_producers) == 0 {
+		return fmt.Errorf("Empty block producer set for epoch %v", epoch_id)
+	}
+
+	bps_hash, err := next_bps_hash(h, block_producers)
+	if err != nil {
+		return fmt.Errorf("Failed to hash block producers: %w", err)
+	}
+
+	if bps_hash != next_bp_hash {
+		return fmt.Errorf("%w: block producers of epoch %v", ErrNextBpHashMismatch, epoch_id)
+	}
+
+	if existing, ok := er.epochs[epoch_id]; ok {
+		existing_hash, err := next_bps_hash(h, existing.block_producers)
+		if err != nil {
+			return fmt.Errorf("Failed to hash block producers: %w", err)
+		}
+
+		if existing_hash != bps_hash {
+			return fmt.Errorf("Conflicting block producers for epoch %v", epoch_id)
+		}
+
+		return nil
+	}
+
+	stake, err := total_stake(block_producers)
+	if err != nil {
+		return err
+	}
+
+	er.epochs[epoch_id] = epoch_entry{
+		next_bp_hash:    next_bp_hash,
+		block_producers: block_producers,
+		total_stake:     stake,
+	}
+	er.order = append(er.order, epoch_id)
+
+	er.prune()
+
+	return nil
+}
+
+// InsertFromBlock records the next block producers announced by block_view.
+func (er *EpochRegistry) InsertFromBlock(h nearprimitive.HostFunction, block_view nearprimitive.LightClientBlockView) error {
+	return er.Insert(h, block_view.InnerLite.NextEpochId, block_view.InnerLite.NextBpHash, block_view.NextBps)
+}
+
+func (er *EpochRegistry) prune() {
+	if er.policy == nil {
+		return
+	}
+
+	to_prune := map[nearprimitive.CryptoHash]bool{}
+	for _, epoch_id := range er.policy.EpochsToPrune(er.order) {
+		to_prune[epoch_id] = true
+	}
+
+	if len(to_prune) == 0 {
+		return
+	}
+
+	order := []nearprimitive.CryptoHash{}
+	for _, epoch_id := range er.order {
+		if to_prune[epoch_id] {
+			delete(er.epochs, epoch_id)
+			continue
+		}
+
+		order = append(order, epoch_id)
+	}
+
+	er.order = order
+}
+
+func (er *EpochRegistry) BlockProducers(epoch_id nearprimitive.CryptoHash) ([]nearprimitive.ValidatorStakeView, error) {
+	entry, ok := er.epochs[epoch_id]
+	if !ok {
+		return nil, fmt.Errorf("%w %v", ErrUnknownEpoch, epoch_id)
+	}
+
+	return entry.block_producers, nil
+}
+
+// TotalStake returns the summed stake of the epoch's block producers, as
+// cached when they were inserted.
+func (er *EpochRegistry) TotalStake(epoch_id nearprimitive.CryptoHash) (num.U128, error) {
+	entry, ok := er.epochs[epoch_id]
+	if !ok {
+		return num.U128{}, fmt.Errorf("%w %v", ErrUnknownEpoch, epoch_id)
+	}
+
+	return entry.total_stake, nil
+}
+
+func (er *EpochRegistry) Contains(epoch_id nearprimitive.CryptoHash) bool {
+	_, ok := er.epochs[epoch_id]
+	return ok
+}
+
+// EpochIds returns the tracked epoch ids, oldest first.
+func (er *EpochRegistry) EpochIds() []nearprimitive.CryptoHash {
+	return append([]nearprimitive.CryptoHash{}, er.order...)
+}
+
+func (er *EpochRegistry) Len() int {
+	return len(er.order)
+}
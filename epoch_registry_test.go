@@ -0,0 +1,155 @@
+// Copyright © 2022, Electron Labs
+
+package light
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/electron-labs/near-light-client-go/mock"
+	"github.com/electron-labs/near-light-client-go/nearprimitive"
+	num "github.com/shabbyrobe/go-num"
+)
+
+func TestEpochRegistryInsert(t *testing.T) {
+	pre_epoch, err := GetClientBlockView(CLIENT_RESPONSE_PREVIOUS_EPOCH)
+	if err != nil {
+		t.Errorf("Failed to parse prev client block: %s", err)
+	}
+
+	h := mock.MockHostFunction{}
+	er := NewEpochRegistry(nil)
+
+	err = er.Insert(h, pre_epoch.InnerLite.NextEpochId, nearprimitive.CryptoHash{}, pre_epoch.NextBps)
+	if err == nil {
+		t.Errorf("Inserted block producers that don't match the next bp hash")
+	}
+
+	err = er.InsertFromBlock(h, pre_epoch)
+	if err != nil {
+		t.Errorf("Failed to insert block producers: %s", err)
+	}
+
+	bps, err := er.BlockProducers(pre_epoch.InnerLite.NextEpochId)
+	if err != nil {
+		t.Errorf("Failed to get block producers: %s", err)
+	}
+
+	if len(bps) != len(pre_epoch.NextBps) {
+		t.Errorf("Unexpected number of block producers: %d", len(bps))
+	}
+
+	expected_total_stake := num.U128{}
+	for _, bp := range pre_epoch.NextBps {
+		expected_total_stake = expected_total_stake.Add(bp.V1.Stake.U128())
+	}
+
+	total_stake, err := er.TotalStake(pre_epoch.InnerLite.NextEpochId)
+	if err != nil {
+		t.Errorf("Failed to get total stake: %s", err)
+	}
+
+	if !total_stake.Equal(expected_total_stake) {
+		t.Errorf("Unexpected total stake %s, expected %s", total_stake, expected_total_stake)
+	}
+
+	_, err = er.BlockProducers(pre_epoch.InnerLite.EpochId)
+	if err == nil {
+		t.Errorf("Lookup of an unknown epoch should fail")
+	}
+
+	_, err = er.TotalStake(pre_epoch.InnerLite.EpochId)
+	if err == nil {
+		t.Errorf("Total stake of an unknown epoch should fail")
+	}
+}
+
+func TestEpochRegistryPruning(t *testing.T) {
+	pre_epoch, err := GetClientBlockView(CLIENT_RESPONSE_PREVIOUS_EPOCH)
+	if err != nil {
+		t.Errorf("Failed to parse prev client block: %s", err)
+	}
+
+	curr_epoch, err := GetClientBlockView(CLIENT_BLOCK_RESPONSE)
+	if err != nil {
+		t.Errorf("Failed to parse current client block: %s", err)
+	}
+
+	h := mock.MockHostFunction{}
+	er := NewEpochRegistry(KeepLastEpochs(1))
+
+	err = er.InsertFromBlock(h, pre_epoch)
+	if err != nil {
+		t.Errorf("Failed to insert block producers: %s", err)
+	}
+
+	err = er.InsertFromBlock(h, curr_epoch)
+	if err != nil {
+		t.Errorf("Failed to insert block producers: %s", err)
+	}
+
+	if er.Len() != 1 {
+		t.Errorf("Expected a single epoch after pruning, got %d", er.Len())
+	}
+
+	if er.Contains(pre_epoch.InnerLite.NextEpochId) {
+		t.Errorf("Oldest epoch was not pruned")
+	}
+
+	if !er.Contains(curr_epoch.InnerLite.NextEpochId) {
+		t.Errorf("Newest epoch was pruned")
+	}
+}
+
+func TestValidateLightBlockUnknownEpoch(t *testing.T) {
+	pre_epoch, err := GetClientBlockView(CLIENT_RESPONSE_PREVIOUS_EPOCH)
+	if err != nil {
+		t.Errorf("Failed to parse prev client block: %s", err)
+	}
+
+	curr_epoch, err := GetClientBlockView(CLIENT_BLOCK_RESPONSE)
+	if err != nil {
+		t.Errorf("Failed to parse current client block: %s", err)
+	}
+
+	err = ValidateLightBlock(mock.MockHostFunction{}, &pre_epoch, &curr_epoch, map[nearprimitive.CryptoHash][]nearprimitive.ValidatorStakeView{})
+	if err == nil {
+		t.Errorf("Block validated without known block producers")
+	}
+}
+
+func TestValidationUsesCachedTotalStake(t *testing.T) {
+	pre_epoch, err := GetClientBlockView(CLIENT_RESPONSE_PREVIOUS_EPOCH)
+	if err != nil {
+		t.Fatalf("Failed to parse prev client block: %s", err)
+	}
+
+	curr_epoch, err := GetClientBlockView(CLIENT_BLOCK_RESPONSE)
+	if err != nil {
+		t.Fatalf("Failed to parse current client block: %s", err)
+	}
+
+	h := mock.MockHostFunction{}
+	er := NewEpochRegistry(nil)
+
+	err = er.InsertFromBlock(h, pre_epoch)
+	if err != nil {
+		t.Fatalf("Failed to insert block producers: %s", err)
+	}
+
+	report, err := validate_light_block(h, &pre_epoch, &curr_epoch, er, new_validate_config(nil))
+	if err != nil {
+		t.Fatalf("Failed to validate block: %s", err)
+	}
+
+	// Validation reads the cache instead of summing the stakes again
+	epoch_id := pre_epoch.InnerLite.NextEpochId
+	entry := er.epochs[epoch_id]
+	entry.total_stake = report.TotalStake.Mul64(2)
+	er.epochs[epoch_id] = entry
+
+	report, err = validate_light_block(h, &pre_epoch, &curr_epoch, er, new_validate_config(nil))
+	if !errors.Is(err, ErrInsufficientStake) || !report.TotalStake.Equal(entry.total_stake) {
+		t.Errorf("Expected ErrInsufficientStake against the cached total, got %v", err)
+	}
+}
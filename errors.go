@@ -0,0 +1,73 @@
+// Copyright © 2022, Electron Labs
+
+package light
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/electron-labs/near-light-client-go/nearprimitive"
+	num "github.com/shabbyrobe/go-num"
+)
+
+var (
+	// The block is not ahead of the head it is validated against
+	ErrHeightNotAhead = errors.New("block height is not ahead of the head")
+	// The block producers of the block's epoch are not known
+	ErrUnknownEpoch = errors.New("unknown epoch")
+	// The block's timestamp is not after the head's
+	ErrTimestampNotAhead = errors.New("block timestamp is not ahead of the head")
+	// The block's timestamp is further in the future than allowed
+	ErrTimestampInFuture = errors.New("block timestamp is in the future")
+	// The block starts the head's next epoch without announcing its next block
+	// producers
+	ErrMissingNextBps = errors.New("missing next block producers")
+	// The block has fewer approvals than its epoch has block producers
+	ErrApprovalCountMismatch = errors.New("approval count mismatch")
+	// The approvals don't reach more than 2/3 of the epoch's stake
+	ErrInsufficientStake = errors.New("insufficient approved stake")
+	// An approval doesn't verify against its block producer's key
+	ErrInvalidSignature = errors.New("invalid approval signature")
+	// The next block producers don't hash to the announced next_bp_hash
+	ErrNextBpHashMismatch = errors.New("next block producers hash mismatch")
+	// The outcome proof doesn't lead to the expected outcome root
+	ErrOutcomeRootMismatch = errors.New("outcome root mismatch")
+	// The block header proof doesn't lead to the expected block merkle root
+	ErrBlockMerkleRootMismatch = errors.New("block merkle root mismatch")
+	// The outcome proof is for another block than the proven block header
+	ErrBlockHashMismatch = errors.New("outcome block hash mismatch")
+	// The proof is for another transaction or receipt than requested
+	ErrOutcomeIdMismatch = errors.New("outcome id mismatch")
+	// The receipt was not executed by the requested receiver
+	ErrExecutorMismatch = errors.New("outcome executor mismatch")
+)
+
+// InsufficientStakeError carries the stakes behind ErrInsufficientStake.
+type InsufficientStakeError struct {
+	ApprovedStake num.U128
+	TotalStake    num.U128
+}
+
+func (e *InsufficientStakeError) Error() string {
+	return fmt.Sprintf("%s: %s of %s", ErrInsufficientStake, e.ApprovedStake, e.TotalStake)
+}
+
+func (e *InsufficientStakeError) Unwrap() error {
+	return ErrInsufficientStake
+}
+
+// InvalidSignatureError identifies the block producer behind
+// ErrInvalidSignature.
+type InvalidSignatureError struct {
+	// Index of the approval, and of the producer in its epoch
+	Index     int
+	AccountId nearprimitive.AccountId
+}
+
+func (e *InvalidSignatureError) Error() string {
+	return fmt.Sprintf("%s: approval %d from %s", ErrInvalidSignature, e.Index, e.AccountId)
+}
+
+func (e *InvalidSignatureError) Unwrap() error {
+	return ErrInvalidSignature
+}
@@ -0,0 +1,207 @@
+// Copyright © 2022, Electron Labs
+
+package light
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/electron-labs/near-light-client-go/mock"
+	"github.com/electron-labs/near-light-client-go/nearprimitive"
+)
+
+func TestValidateLightBlockErrors(t *testing.T) {
+	h := mock.MockHostFunction{}
+	validators := new_synthetic_validators(t, "bp", 4)
+	next_validators := new_synthetic_stake_views(t, "next", 4)
+
+	head := new_synthetic_block(t, h, 100, synthetic_epoch_0, synthetic_epoch_1, synthetic_stake_views(validators), 0)
+	producers := map[nearprimitive.CryptoHash][]nearprimitive.ValidatorStakeView{
+		synthetic_epoch_1: synthetic_stake_views(validators),
+	}
+
+	stale := new_synthetic_block(t, h, 100, synthetic_epoch_1, synthetic_epoch_2, next_validators, 1)
+	sign_synthetic_block(t, h, &stale, validators, all_sign)
+
+	err := ValidateLightBlock(h, &head, &stale, producers)
+	if !errors.Is(err, ErrHeightNotAhead) {
+		t.Errorf("Expected ErrHeightNotAhead, got %v", err)
+	}
+
+	unknown := new_synthetic_block(t, h, 110, synthetic_epoch_2, synthetic_epoch_2, next_validators, 1)
+	sign_synthetic_block(t, h, &unknown, validators, all_sign)
+
+	err = ValidateLightBlock(h, &head, &unknown, producers)
+	if !errors.Is(err, ErrUnknownEpoch) {
+		t.Errorf("Expected ErrUnknownEpoch, got %v", err)
+	}
+
+	missing := new_synthetic_block(t, h, 110, synthetic_epoch_1, synthetic_epoch_2, nil, 1)
+	sign_synthetic_block(t, h, &missing, validators, all_sign)
+
+	err = ValidateLightBlock(h, &head, &missing, producers)
+	if !errors.Is(err, ErrMissingNextBps) {
+		t.Errorf("Expected ErrMissingNextBps, got %v", err)
+	}
+
+	// Stakes are equal, 2 of 4 is not more than 2/3
+	insufficient := new_synthetic_block(t, h, 110, synthetic_epoch_1, synthetic_epoch_2, next_validators, 1)
+	sign_synthetic_block(t, h, &insufficient, validators, func(i int) bool { return i < 2 })
+
+	err = ValidateLightBlock(h, &head, &insufficient, producers)
+	var stake_err *InsufficientStakeError
+	if !errors.Is(err, ErrInsufficientStake) || !errors.As(err, &stake_err) {
+		t.Fatalf("Expected ErrInsufficientStake, got %v", err)
+	}
+
+	total_stake := validators[0].stake_view.V1.Stake.U128().Mul64(4)
+	approved_stake := validators[0].stake_view.V1.Stake.U128().Mul64(2)
+	if !stake_err.TotalStake.Equal(total_stake) || !stake_err.ApprovedStake.Equal(approved_stake) {
+		t.Errorf("Unexpected stakes %s of %s", stake_err.ApprovedStake, stake_err.TotalStake)
+	}
+
+	forged := new_synthetic_block(t, h, 110, synthetic_epoch_1, synthetic_epoch_2, next_validators, 1)
+	sign_synthetic_block(t, h, &forged, validators, all_sign)
+
+	_, _, approval_message, err := reconstruct_light_client_block_view_fields(h, forged)
+	if err != nil {
+		t.Fatalf("Failed to build approval message: %s", err)
+	}
+
+	// validators[2] approves with validators[3]'s key
+	forged_signature := &nearprimitive.Signature{}
+	err = forged_signature.TryFromRaw(ed25519.Sign(validators[3].private_key, approval_message))
+	if err != nil {
+		t.Fatalf("Failed to create signature: %s", err)
+	}
+	forged.ApprovalsAfterNext[2] = forged_signature
+
+	err = ValidateLightBlock(h, &head, &forged, producers)
+	var signature_err *InvalidSignatureError
+	if !errors.Is(err, ErrInvalidSignature) || !errors.As(err, &signature_err) {
+		t.Fatalf("Expected ErrInvalidSignature, got %v", err)
+	}
+
+	if signature_err.Index != 2 || signature_err.AccountId != validators[2].stake_view.V1.AccountId {
+		t.Errorf("Unexpected invalid signature from %d %s", signature_err.Index, signature_err.AccountId)
+	}
+
+	// next_bps is not covered by the approvals
+	mismatch := new_synthetic_block(t, h, 110, synthetic_epoch_1, synthetic_epoch_2, next_validators, 1)
+	sign_synthetic_block(t, h, &mismatch, validators, all_sign)
+	mismatch.NextBps = synthetic_stake_views(validators)
+
+	err = ValidateLightBlock(h, &head, &mismatch, producers)
+	if !errors.Is(err, ErrNextBpHashMismatch) {
+		t.Errorf("Expected ErrNextBpHashMismatch, got %v", err)
+	}
+}
+
+func TestLightClientErrorsAreWrapped(t *testing.T) {
+	pre_epoch, err := GetClientBlockView(CLIENT_RESPONSE_PREVIOUS_EPOCH)
+	if err != nil {
+		t.Errorf("Failed to parse prev client block: %s", err)
+	}
+
+	curr_epoch, err := GetClientBlockView(CLIENT_BLOCK_RESPONSE)
+	if err != nil {
+		t.Errorf("Failed to parse current client block: %s", err)
+	}
+
+	lc := NewLightClient(mock.MockHostFunction{})
+	err = lc.NewFromCheckpoint(pre_epoch, 1)
+	if err != nil {
+		t.Fatalf("Failed to bootstrap: %s", err)
+	}
+
+	err = lc.ValidateAndUpdateHead(curr_epoch)
+	if err != nil {
+		t.Errorf("Failed to validate block: %s", err)
+	}
+
+	err = lc.ValidateAndUpdateHead(curr_epoch)
+	if !errors.Is(err, ErrHeightNotAhead) {
+		t.Errorf("Expected ErrHeightNotAhead, got %v", err)
+	}
+
+	lc = NewLightClient(mock.MockHostFunction{}, WithStore(&failing_store{fail: true}))
+	err = lc.Bootstrap(pre_epoch, 1)
+	if !errors.Is(err, err_disk_full) {
+		t.Errorf("Expected the store error, got %v", err)
+	}
+}
+
+func TestValidateTransactionErrors(t *testing.T) {
+	outcome_proof, merkle_path, _, err := GetOutcomeProof(TRANSACTION_PROOF)
+	if err != nil {
+		t.Fatalf("Failed to parse outcome proof: %s", err)
+	}
+
+	err = ValidateTransaction(mock.MockHostFunction{}, outcome_proof, merkle_path, nearprimitive.CryptoHash{})
+	if !errors.Is(err, ErrOutcomeRootMismatch) {
+		t.Errorf("Expected ErrOutcomeRootMismatch, got %v", err)
+	}
+
+	_, _, _, err = GetOutcomeProof(`{"result": {"outcome_proof": {"id": "1"}}}`)
+	if err == nil {
+		t.Errorf("Parsed an invalid outcome proof")
+	}
+}
+
+func TestBlockMerkleRootVerificationErrors(t *testing.T) {
+	err := BlockMerkleRootVerification(mock.MockHostFunction{}, CLIENT_BLOCK_RESPONSE, EXECUTION_OUTCOME)
+	if !errors.Is(err, ErrBlockMerkleRootMismatch) {
+		t.Errorf("Expected ErrBlockMerkleRootMismatch, got %v", err)
+	}
+}
+
+func TestValidateLightBlockApprovalCount(t *testing.T) {
+	h := mock.MockHostFunction{}
+	validators := new_synthetic_validators(t, "bp", 4)
+	next_validators := new_synthetic_stake_views(t, "next", 4)
+
+	head := new_synthetic_block(t, h, 100, synthetic_epoch_0, synthetic_epoch_1, synthetic_stake_views(validators), 0)
+	producers := map[nearprimitive.CryptoHash][]nearprimitive.ValidatorStakeView{
+		synthetic_epoch_1: synthetic_stake_views(validators),
+	}
+
+	truncated := new_synthetic_block(t, h, 110, synthetic_epoch_1, synthetic_epoch_2, next_validators, 1)
+	sign_synthetic_block(t, h, &truncated, validators, all_sign)
+	truncated.ApprovalsAfterNext = truncated.ApprovalsAfterNext[:3]
+
+	err := ValidateLightBlock(h, &head, &truncated, producers)
+	if !errors.Is(err, ErrApprovalCountMismatch) {
+		t.Errorf("Expected ErrApprovalCountMismatch, got %v", err)
+	}
+
+	err = ValidateLightBlock(h, &head, &truncated, nil)
+	if !errors.Is(err, ErrUnknownEpoch) {
+		t.Errorf("Expected ErrUnknownEpoch, got %v", err)
+	}
+
+	valid := new_synthetic_block(t, h, 110, synthetic_epoch_1, synthetic_epoch_2, next_validators, 1)
+	sign_synthetic_block(t, h, &valid, validators, all_sign)
+
+	err = ValidateLightBlock(h, &head, &valid, producers)
+	if err != nil {
+		t.Errorf("Failed to validate block: %s", err)
+	}
+}
+
+func TestGetClientBlockViewRejectsUnknownStakeVersion(t *testing.T) {
+	response := strings.Replace(CLIENT_RESPONSE_PREVIOUS_EPOCH, `"validator_stake_struct_version": "V1"`, `"validator_stake_struct_version": "V2"`, 1)
+
+	_, err := GetClientBlockView(response)
+	if !errors.Is(err, nearprimitive.ErrUnknownValidatorStakeVersion) {
+		t.Errorf("Expected ErrUnknownValidatorStakeVersion, got %v", err)
+	}
+
+	response = strings.Replace(CLIENT_RESPONSE_PREVIOUS_EPOCH, `"public_key": "ed25519:`, `"public_key": "secp256k1:`, 1)
+
+	_, err = GetClientBlockView(response)
+	if err == nil {
+		t.Errorf("Parsed a secp256k1 block producer key")
+	}
+}
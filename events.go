@@ -0,0 +1,128 @@
+// Copyright © 2022, Electron Labs
+
+package light
+
+import (
+	"github.com/electron-labs/near-light-client-go/nearprimitive"
+)
+
+// Event is emitted by a LightClient to its subscribers. It is one of
+// NewHeadEvent, EpochTransitionEvent, ValidationFailedEvent or
+// ConflictDetectedEvent.
+type Event interface {
+	event()
+}
+
+// NewHeadEvent is emitted after the head advanced.
+type NewHeadEvent struct {
+	BlockHash nearprimitive.CryptoHash
+	Head      nearprimitive.LightClientBlockView
+}
+
+// EpochTransitionEvent is emitted when the block producers of a new epoch are
+// accepted.
+type EpochTransitionEvent struct {
+	EpochId nearprimitive.CryptoHash
+	NextBps []nearprimitive.ValidatorStakeView
+	// The block that announced NextBps
+	BlockHash nearprimitive.CryptoHash
+	Height    nearprimitive.BlockHeight
+}
+
+// ValidationFailedEvent is emitted when a block is rejected.
+type ValidationFailedEvent struct {
+	Err   error
+	Block nearprimitive.LightClientBlockView
+}
+
+// ConflictDetectedEvent is emitted once, when the client halts on
+// equivocation.
+type ConflictDetectedEvent struct {
+	Evidence *EquivocationEvidence
+}
+
+func (NewHeadEvent) event()          {}
+func (EpochTransitionEvent) event()  {}
+func (ValidationFailedEvent) event() {}
+func (ConflictDetectedEvent) event() {}
+
+// EventHandler is called in subscription order, with no client lock held, by
+// the goroutine that updated the client. Handlers may update the client
+// themselves: the events of such nested updates are delivered once the current
+// handler returns. While another goroutine is delivering events, an update
+// returns without waiting for its events to be delivered.
+type EventHandler func(Event)
+
+type subscription struct {
+	id      uint64
+	handler EventHandler
+}
+
+// Subscribe registers handler for every event emitted from now on. Calling
+// the returned function unsubscribes it.
+func (lc *LightClient) Subscribe(handler EventHandler) func() {
+	lc.subscriptions_mu.Lock()
+	defer lc.subscriptions_mu.Unlock()
+
+	lc.next_subscription_id++
+	id := lc.next_subscription_id
+	lc.subscriptions = append(lc.subscriptions, subscription{id: id, handler: handler})
+
+	return func() {
+		lc.subscriptions_mu.Lock()
+		defer lc.subscriptions_mu.Unlock()
+
+		for i, s := range lc.subscriptions {
+			if s.id == id {
+				lc.subscriptions = append(lc.subscriptions[:i:i], lc.subscriptions[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// queue must be called with mu held.
+func (lc *LightClient) queue(events []Event) {
+	if len(events) == 0 {
+		return
+	}
+
+	lc.emit_mu.Lock()
+	lc.pending = append(lc.pending, events...)
+	lc.emit_mu.Unlock()
+}
+
+// dispatch delivers the queued events unless another call is already doing
+// so, it must be called without mu held.
+func (lc *LightClient) dispatch() {
+	lc.emit_mu.Lock()
+	if lc.dispatching {
+		lc.emit_mu.Unlock()
+		return
+	}
+	lc.dispatching = true
+
+	for len(lc.pending) > 0 {
+		events := lc.pending
+		lc.pending = nil
+
+		lc.emit_mu.Unlock()
+		lc.emit(events...)
+		lc.emit_mu.Lock()
+	}
+
+	lc.dispatching = false
+	lc.emit_mu.Unlock()
+}
+
+func (lc *LightClient) emit(events ...Event) {
+	lc.subscriptions_mu.Lock()
+	subscriptions := lc.subscriptions
+	lc.subscriptions_mu.Unlock()
+
+	for _, e := range events {
+		for _, s := range subscriptions {
+			s.handler(e)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright © 2022, Electron Labs
+
+package light
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/electron-labs/near-light-client-go/mock"
+)
+
+func TestLightClientEvents(t *testing.T) {
+	pre_epoch, err := GetClientBlockView(CLIENT_RESPONSE_PREVIOUS_EPOCH)
+	if err != nil {
+		t.Errorf("Failed to parse prev client block: %s", err)
+	}
+
+	curr_epoch, err := GetClientBlockView(CLIENT_BLOCK_RESPONSE)
+	if err != nil {
+		t.Errorf("Failed to parse current client block: %s", err)
+	}
+
+	next_epoch, err := GetClientBlockView(CLIENT_BLOCK_RESPONSE_NEXT_BLOCK)
+	if err != nil {
+		t.Errorf("Failed to parse next client block: %s", err)
+	}
+
+	lc := NewLightClient(mock.MockHostFunction{})
+	err = lc.Bootstrap(pre_epoch, 10)
+	if err != nil {
+		t.Errorf("Failed to bootstrap: %s", err)
+	}
+
+	events := []Event{}
+	unsubscribe := lc.Subscribe(func(e Event) {
+		events = append(events, e)
+	})
+
+	err = lc.ValidateAndUpdateHead(curr_epoch)
+	if err != nil {
+		t.Errorf("Failed to validate block: %s", err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+
+	epoch_transition, ok := events[0].(EpochTransitionEvent)
+	if !ok {
+		t.Fatalf("Expected an EpochTransitionEvent, got %T", events[0])
+	}
+
+	if epoch_transition.EpochId != curr_epoch.InnerLite.NextEpochId || len(epoch_transition.NextBps) != len(curr_epoch.NextBps) {
+		t.Errorf("Unexpected epoch transition: %v", epoch_transition.EpochId)
+	}
+
+	new_head, ok := events[1].(NewHeadEvent)
+	if !ok {
+		t.Fatalf("Expected a NewHeadEvent, got %T", events[1])
+	}
+
+	if new_head.Head.InnerLite.Height != curr_epoch.InnerLite.Height || new_head.BlockHash != lc.HeadHash() {
+		t.Errorf("Unexpected new head: %d", new_head.Head.InnerLite.Height)
+	}
+
+	// Same epoch, same next block producers: no transition
+	err = lc.ValidateAndUpdateHead(next_epoch)
+	if err != nil {
+		t.Errorf("Failed to validate block: %s", err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(events))
+	}
+
+	if _, ok := events[2].(NewHeadEvent); !ok {
+		t.Errorf("Expected a NewHeadEvent, got %T", events[2])
+	}
+
+	err = lc.ValidateAndUpdateHead(curr_epoch)
+	if err == nil {
+		t.Errorf("Block verification succeded but it should not!!!")
+	}
+
+	validation_failed, ok := events[3].(ValidationFailedEvent)
+	if !ok {
+		t.Fatalf("Expected a ValidationFailedEvent, got %T", events[3])
+	}
+
+	if validation_failed.Err == nil || validation_failed.Block.InnerLite.Height != curr_epoch.InnerLite.Height {
+		t.Errorf("Unexpected validation failure: %v", validation_failed)
+	}
+
+	unsubscribe()
+
+	_ = lc.ValidateAndUpdateHead(curr_epoch)
+	if len(events) != 4 {
+		t.Errorf("Received events after unsubscribing")
+	}
+}
+
+func TestLightClientConflictEvent(t *testing.T) {
+	h := mock.MockHostFunction{}
+	validators := new_synthetic_validators(t, "bp", 4)
+	next_validators := new_synthetic_stake_views(t, "next", 4)
+
+	lc := new_synthetic_client(t, h, validators)
+
+	conflicts := 0
+	lc.Subscribe(func(e Event) {
+		if _, ok := e.(ConflictDetectedEvent); ok {
+			conflicts++
+		}
+	})
+
+	for salt := byte(1); salt <= 3; salt++ {
+		block_view := new_synthetic_block(t, h, 110, synthetic_epoch_1, synthetic_epoch_2, next_validators, salt)
+		sign_synthetic_block(t, h, &block_view, validators, all_sign)
+
+		_ = lc.ValidateAndUpdateHead(block_view)
+	}
+
+	if conflicts != 1 {
+		t.Errorf("Expected a single ConflictDetectedEvent, got %d", conflicts)
+	}
+}
+
+var err_disk_full = errors.New("disk full")
+
+// failing_store fails every save once fail is set
+type failing_store struct {
+	MemoryStore
+	fail bool
+}
+
+func (fs *failing_store) Save(state *LightClientState) error {
+	if fs.fail {
+		return err_disk_full
+	}
+
+	return fs.MemoryStore.Save(state)
+}
+
+func TestLightClientUpdateNotSaved(t *testing.T) {
+	pre_epoch, err := GetClientBlockView(CLIENT_RESPONSE_PREVIOUS_EPOCH)
+	if err != nil {
+		t.Fatalf("Failed to parse prev client block: %s", err)
+	}
+
+	curr_epoch, err := GetClientBlockView(CLIENT_BLOCK_RESPONSE)
+	if err != nil {
+		t.Fatalf("Failed to parse current client block: %s", err)
+	}
+
+	store := &failing_store{}
+	lc := NewLightClient(mock.MockHostFunction{}, WithStore(store))
+	err = lc.Bootstrap(pre_epoch, 10)
+	if err != nil {
+		t.Fatalf("Failed to bootstrap: %s", err)
+	}
+
+	events := []Event{}
+	lc.Subscribe(func(e Event) {
+		events = append(events, e)
+	})
+
+	store.fail = true
+	err = lc.ValidateAndUpdateHead(curr_epoch)
+	if err == nil {
+		t.Fatalf("Updated the head without saving it")
+	}
+
+	if lc.CurrentBlockHeight() != uint64(pre_epoch.InnerLite.Height) || len(events) != 0 {
+		t.Errorf("Head moved to %d and %d events were emitted", lc.CurrentBlockHeight(), len(events))
+	}
+
+	if lc.epochs.Contains(curr_epoch.InnerLite.NextEpochId) {
+		t.Errorf("Next block producers were recorded")
+	}
+
+	store.fail = false
+	err = lc.ValidateAndUpdateHead(curr_epoch)
+	if err != nil {
+		t.Errorf("Failed to validate block: %s", err)
+	}
+
+	if lc.CurrentBlockHeight() != uint64(curr_epoch.InnerLite.Height) || len(events) != 2 {
+		t.Errorf("Head at %d after %d events", lc.CurrentBlockHeight(), len(events))
+	}
+}
+
+func TestLightClientHandlerUpdatesClient(t *testing.T) {
+	pre_epoch, err := GetClientBlockView(CLIENT_RESPONSE_PREVIOUS_EPOCH)
+	if err != nil {
+		t.Fatalf("Failed to parse prev client block: %s", err)
+	}
+
+	curr_epoch, err := GetClientBlockView(CLIENT_BLOCK_RESPONSE)
+	if err != nil {
+		t.Fatalf("Failed to parse current client block: %s", err)
+	}
+
+	next_epoch, err := GetClientBlockView(CLIENT_BLOCK_RESPONSE_NEXT_BLOCK)
+	if err != nil {
+		t.Fatalf("Failed to parse next client block: %s", err)
+	}
+
+	lc := NewLightClient(mock.MockHostFunction{})
+	err = lc.Bootstrap(pre_epoch, 10)
+	if err != nil {
+		t.Fatalf("Failed to bootstrap: %s", err)
+	}
+
+	heights := []uint64{}
+	lc.Subscribe(func(e Event) {
+		new_head, ok := e.(NewHeadEvent)
+		if !ok {
+			return
+		}
+
+		heights = append(heights, uint64(new_head.Head.InnerLite.Height))
+		if new_head.Head.InnerLite.Height == curr_epoch.InnerLite.Height {
+			err := lc.ValidateAndUpdateHead(next_epoch)
+			if err != nil {
+				t.Errorf("Failed to validate block from a handler: %s", err)
+			}
+		}
+	})
+
+	err = lc.ValidateAndUpdateHead(curr_epoch)
+	if err != nil {
+		t.Fatalf("Failed to validate block: %s", err)
+	}
+
+	if len(heights) != 2 || heights[0] != uint64(curr_epoch.InnerLite.Height) || heights[1] != uint64(next_epoch.InnerLite.Height) {
+		t.Errorf("Unexpected new heads %v", heights)
+	}
+}
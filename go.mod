@@ -2,13 +2,17 @@ module github.com/electron-labs/near-light-client-go
 
 go 1.18
 
+require (
+	filippo.io/edwards25519 v1.0.0
+	github.com/btcsuite/btcutil v1.0.2
+	github.com/near/borsh-go v0.3.1
+	github.com/shabbyrobe/go-num v0.0.0-20220218224608-bad1c8f534d7
+)
+
 require (
 	github.com/BurntSushi/toml v1.2.0 // indirect
-	github.com/btcsuite/btcutil v1.0.2 // indirect
 	github.com/google/go-cmp v0.5.8 // indirect
-	github.com/near/borsh-go v0.3.1 // indirect
 	github.com/sergi/go-diff v1.1.0 // indirect
-	github.com/shabbyrobe/go-num v0.0.0-20220218224608-bad1c8f534d7 // indirect
 	golang.org/x/exp v0.0.0-20220722155223-a9213eeb770e // indirect
 	golang.org/x/exp/typeparams v0.0.0-20220722155223-a9213eeb770e // indirect
 	golang.org/x/mod v0.6.0-dev.0.20220419223038-86c51ed26bb4 // indirect
@@ -0,0 +1,103 @@
+// Copyright © 2022, Electron Labs
+
+package light
+
+import (
+	"github.com/electron-labs/near-light-client-go/nearprimitive"
+)
+
+// HeadHistory keeps the most recently accepted heads, indexed by height and
+// by block hash. Once more than capacity heads are stored the oldest one is
+// evicted.
+type HeadHistory struct {
+	capacity  uint64
+	order     []nearprimitive.CryptoHash
+	by_height map[nearprimitive.BlockHeight]nearprimitive.CryptoHash
+	by_hash   map[nearprimitive.CryptoHash]nearprimitive.LightClientBlockView
+}
+
+// NewHeadHistory creates a history holding up to capacity heads. A capacity
+// of zero still keeps the latest head.
+func NewHeadHistory(capacity uint64) *HeadHistory {
+	if capacity == 0 {
+		capacity = 1
+	}
+
+	return &HeadHistory{
+		capacity:  capacity,
+		order:     []nearprimitive.CryptoHash{},
+		by_height: map[nearprimitive.BlockHeight]nearprimitive.CryptoHash{},
+		by_hash:   map[nearprimitive.CryptoHash]nearprimitive.LightClientBlockView{},
+	}
+}
+
+func (hh *HeadHistory) clone() *HeadHistory {
+	res := NewHeadHistory(hh.capacity)
+	res.order = append(res.order, hh.order...)
+	for height, block_hash := range hh.by_height {
+		res.by_height[height] = block_hash
+	}
+	for block_hash, head := range hh.by_hash {
+		res.by_hash[block_hash] = head
+	}
+
+	return res
+}
+
+func (hh *HeadHistory) Add(block_hash nearprimitive.CryptoHash, head nearprimitive.LightClientBlockView) {
+	if _, ok := hh.by_hash[block_hash]; ok {
+		return
+	}
+
+	hh.order = append(hh.order, block_hash)
+	hh.by_hash[block_hash] = head
+	hh.by_height[head.InnerLite.Height] = block_hash
+
+	for uint64(len(hh.order)) > hh.capacity {
+		hh.evict_oldest()
+	}
+}
+
+func (hh *HeadHistory) evict_oldest() {
+	oldest := hh.order[0]
+	hh.order = hh.order[1:]
+
+	head := hh.by_hash[oldest]
+	delete(hh.by_hash, oldest)
+
+	if hh.by_height[head.InnerLite.Height] == oldest {
+		delete(hh.by_height, head.InnerLite.Height)
+	}
+}
+
+func (hh *HeadHistory) GetByHash(block_hash nearprimitive.CryptoHash) (nearprimitive.LightClientBlockView, bool) {
+	head, ok := hh.by_hash[block_hash]
+	return head, ok
+}
+
+func (hh *HeadHistory) GetByHeight(height nearprimitive.BlockHeight) (nearprimitive.LightClientBlockView, bool) {
+	block_hash, ok := hh.by_height[height]
+	if !ok {
+		return nearprimitive.LightClientBlockView{}, false
+	}
+
+	return hh.GetByHash(block_hash)
+}
+
+// Heads returns the tracked heads, oldest first.
+func (hh *HeadHistory) Heads() []nearprimitive.LightClientBlockView {
+	heads := []nearprimitive.LightClientBlockView{}
+	for _, block_hash := range hh.order {
+		heads = append(heads, hh.by_hash[block_hash])
+	}
+
+	return heads
+}
+
+func (hh *HeadHistory) Len() int {
+	return len(hh.order)
+}
+
+func (hh *HeadHistory) Capacity() uint64 {
+	return hh.capacity
+}
@@ -0,0 +1,64 @@
+// Copyright © 2022, Electron Labs
+
+package light
+
+import (
+	"testing"
+
+	"github.com/electron-labs/near-light-client-go/nearprimitive"
+)
+
+func TestHeadHistoryEviction(t *testing.T) {
+	hh := NewHeadHistory(2)
+
+	heads := []nearprimitive.LightClientBlockView{}
+	hashes := []nearprimitive.CryptoHash{}
+	for i := 0; i < 3; i++ {
+		head := nearprimitive.LightClientBlockView{}
+		head.InnerLite.Height = nearprimitive.BlockHeight(100 + i)
+		hash := nearprimitive.CryptoHash{byte(i + 1)}
+
+		hh.Add(hash, head)
+		heads = append(heads, head)
+		hashes = append(hashes, hash)
+	}
+
+	if hh.Len() != 2 {
+		t.Errorf("Expected 2 tracked heads, got %d", hh.Len())
+	}
+
+	if _, ok := hh.GetByHash(hashes[0]); ok {
+		t.Errorf("Oldest head was not evicted by hash")
+	}
+
+	if _, ok := hh.GetByHeight(heads[0].InnerLite.Height); ok {
+		t.Errorf("Oldest head was not evicted by height")
+	}
+
+	for i := 1; i < 3; i++ {
+		head, ok := hh.GetByHash(hashes[i])
+		if !ok || head.InnerLite.Height != heads[i].InnerLite.Height {
+			t.Errorf("Head %d missing by hash", i)
+		}
+
+		head, ok = hh.GetByHeight(heads[i].InnerLite.Height)
+		if !ok || head.InnerLite.Height != heads[i].InnerLite.Height {
+			t.Errorf("Head %d missing by height", i)
+		}
+	}
+}
+
+func TestHeadHistoryZeroCapacity(t *testing.T) {
+	hh := NewHeadHistory(0)
+
+	hh.Add(nearprimitive.CryptoHash{1}, nearprimitive.LightClientBlockView{})
+	hh.Add(nearprimitive.CryptoHash{2}, nearprimitive.LightClientBlockView{})
+
+	if hh.Len() != 1 {
+		t.Errorf("Expected only the latest head, got %d", hh.Len())
+	}
+
+	if _, ok := hh.GetByHash(nearprimitive.CryptoHash{2}); !ok {
+		t.Errorf("Latest head is not tracked")
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright © 2022, Electron Labs
+
+package light
+
+import (
+	"crypto/ed25519"
+	"crypto/sha512"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/electron-labs/near-light-client-go/mock"
+	"github.com/electron-labs/near-light-client-go/nearprimitive"
+	borsh "github.com/near/borsh-go"
+)
+
+// isolated_host hashes with SHA-512/256 and signs digests instead of messages,
+// so any hash or signature check that bypasses the host gives a different
+// result.
+type isolated_host struct{}
+
+func (isolated_host) Sha256(data []byte) [32]byte {
+	return sha512.Sum512_256(data)
+}
+
+func (isolated_host) Verify(sig nearprimitive.Signature, data []byte, public_key nearprimitive.PublicKey) bool {
+	digest := sha512.Sum512_256(data)
+	return ed25519.Verify(public_key.GetEd25519PubKey(), digest[:], sig.AsBytes())
+}
+
+func (isolated_host) Sign(private_key ed25519.PrivateKey, message []byte) []byte {
+	digest := sha512.Sum512_256(message)
+	return ed25519.Sign(private_key, digest[:])
+}
+
+// counting_host is MockHostFunction counting its calls.
+type counting_host struct {
+	mock.MockHostFunction
+	hashes   int64
+	verifies int64
+}
+
+func (c *counting_host) Sha256(data []byte) [32]byte {
+	atomic.AddInt64(&c.hashes, 1)
+	return c.MockHostFunction.Sha256(data)
+}
+
+func (c *counting_host) Verify(sig nearprimitive.Signature, data []byte, public_key nearprimitive.PublicKey) bool {
+	atomic.AddInt64(&c.verifies, 1)
+	return c.MockHostFunction.Verify(sig, data, public_key)
+}
+
+func TestHostFunctionIsolation(t *testing.T) {
+	h := isolated_host{}
+	validators := new_synthetic_validators(t, "bp", 4)
+	next_validators := new_synthetic_stake_views(t, "next", 4)
+
+	lc := new_synthetic_client(t, h, validators)
+
+	block_view := new_synthetic_block(t, h, 110, synthetic_epoch_1, synthetic_epoch_2, next_validators, 1)
+	sign_synthetic_block(t, h, &block_view, validators, all_sign)
+
+	err := lc.ValidateAndUpdateHead(block_view)
+	if err != nil {
+		t.Fatalf("Failed to validate block: %s", err)
+	}
+
+	ser_inner_lite, err := borsh.Serialize(block_view.InnerLite.ToBlockHeaderInnerLiteViewFinal())
+	if err != nil {
+		t.Fatalf("Failed to serialize inner lite: %s", err)
+	}
+
+	inner_lite_hash := sha512.Sum512_256(ser_inner_lite)
+	inner_hash := sha512.Sum512_256(append(inner_lite_hash[:], block_view.InnerRestHash[:]...))
+	expected_hash := nearprimitive.CryptoHash(sha512.Sum512_256(append(inner_hash[:], block_view.PrevBlockHash[:]...)))
+
+	if lc.HeadHash() != expected_hash {
+		t.Errorf("Block hash was not computed with the host: %v", lc.HeadHash())
+	}
+
+	producers := map[nearprimitive.CryptoHash][]nearprimitive.ValidatorStakeView{
+		synthetic_epoch_1: synthetic_stake_views(validators),
+	}
+
+	head := new_synthetic_block(t, h, 100, synthetic_epoch_0, synthetic_epoch_1, synthetic_stake_views(validators), 0)
+
+	// The same block doesn't verify with regular Sha256 and Ed25519
+	err = ValidateLightBlock(mock.MockHostFunction{}, &head, &block_view, producers)
+	if !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected ErrInvalidSignature, got %v", err)
+	}
+}
+
+func TestHostFunctionIsolationMainnet(t *testing.T) {
+	h := isolated_host{}
+
+	pre_epoch, err := GetClientBlockView(CLIENT_RESPONSE_PREVIOUS_EPOCH)
+	if err != nil {
+		t.Errorf("Failed to parse prev client block: %s", err)
+	}
+
+	lc := NewLightClient(h)
+	err = lc.Bootstrap(pre_epoch, 1)
+	if !errors.Is(err, ErrNextBpHashMismatch) {
+		t.Errorf("Expected ErrNextBpHashMismatch, got %v", err)
+	}
+
+	outcome_proof, merkle_path, expected_block_outcome_root, err := GetOutcomeProof(TRANSACTION_PROOF)
+	if err != nil {
+		t.Fatalf("Failed to parse outcome proof: %s", err)
+	}
+
+	err = ValidateTransaction(h, outcome_proof, merkle_path, expected_block_outcome_root)
+	if !errors.Is(err, ErrOutcomeRootMismatch) {
+		t.Errorf("Expected ErrOutcomeRootMismatch, got %v", err)
+	}
+
+	err = BlockMerkleRootVerification(h, LIGHT_CLIENT_BLOCK, EXECUTION_OUTCOME)
+	if !errors.Is(err, ErrBlockMerkleRootMismatch) {
+		t.Errorf("Expected ErrBlockMerkleRootMismatch, got %v", err)
+	}
+}
@@ -9,10 +9,8 @@ import (
 	"strings"
 
 	"github.com/electron-labs/near-light-client-go/nearprimitive"
-	"github.com/near/borsh-go"
 
 	base58 "github.com/btcsuite/btcutil/base58"
-	num "github.com/shabbyrobe/go-num"
 )
 
 type NearInnerLightView struct {
@@ -155,13 +153,13 @@ type Status struct {
 }
 
 type Outcome struct {
-	ExecutorId  string                     `json:"executor_id"`
-	GasBurnt    uint64                     `json:"gas_burnt"`
-	Logs        []string                   `json:"logs"`
-	MetaData    MetaData                   `json:"metadata"`
-	ReceiptIds  []string                   `json:"receipt_ids"`
-	Status      map[string]json.RawMessage `json:"status"`
-	TokensBurnt string                     `json:"tokens_burnt"`
+	ExecutorId  string                            `json:"executor_id"`
+	GasBurnt    uint64                            `json:"gas_burnt"`
+	Logs        []string                          `json:"logs"`
+	MetaData    MetaData                          `json:"metadata"`
+	ReceiptIds  []string                          `json:"receipt_ids"`
+	Status      nearprimitive.ExecutionStatusView `json:"status"`
+	TokensBurnt string                            `json:"tokens_burnt"`
 }
 
 type Proof []struct {
@@ -251,16 +249,9 @@ func (op OutcomeProof) parse() (nearprimitive.OutcomeProof, error) {
 		}
 	}
 
-	token_burnt, _, _ := num.U128FromString(op.Outcome.TokensBurnt)
-
-	serialized_status, err := nearprimitive.IntoExecutionStatusView(op.Outcome.Status)
-	if err != nil {
-		return nearprimitive.OutcomeProof{}, fmt.Errorf("Failed to parse status: %s", err)
-	}
-
-	ser_status, err := borsh.Serialize(serialized_status)
+	token_burnt, err := nearprimitive.ParseBalance(op.Outcome.TokensBurnt)
 	if err != nil {
-		return nearprimitive.OutcomeProof{}, fmt.Errorf("Failed to serialize status: %s", err)
+		return nearprimitive.OutcomeProof{}, fmt.Errorf("Failed to parse tokens burnt: %w", err)
 	}
 
 	execution_outcome := nearprimitive.ExecutionOutcomeView{
@@ -269,7 +260,7 @@ func (op OutcomeProof) parse() (nearprimitive.OutcomeProof, error) {
 		GasBurnt:    nearprimitive.Gas(op.Outcome.GasBurnt),
 		TokensBurnt: token_burnt,
 		ExecutorId:  nearprimitive.AccountId(op.Outcome.ExecutorId),
-		Status:      ser_status,
+		Status:      op.Outcome.Status,
 	}
 
 	id := &nearprimitive.CryptoHash{}
@@ -280,8 +271,6 @@ func (op OutcomeProof) parse() (nearprimitive.OutcomeProof, error) {
 
 	block_hash := &nearprimitive.CryptoHash{}
 	err = block_hash.TryFromRaw(base58.Decode(op.BlockHash))
-	err = id.TryFromRaw(base58.Decode(op.Id))
-
 	if err != nil {
 		return nearprimitive.OutcomeProof{}, fmt.Errorf("Failed to Decode block Hash: %s", err)
 	}
@@ -301,6 +290,81 @@ func (op OutcomeProof) parse() (nearprimitive.OutcomeProof, error) {
 	return outcome_proof, nil
 }
 
+type OutcomeType uint8
+
+const (
+	TransactionOutcome OutcomeType = iota
+	ReceiptOutcome
+)
+
+// NearProofRequest holds the params of a light_client_proof request.
+// Transaction proofs set TransactionHash and SenderId, receipt proofs set
+// ReceiptId and ReceiverId.
+type NearProofRequest struct {
+	Type            string `json:"type"`
+	TransactionHash string `json:"transaction_hash,omitempty"`
+	SenderId        string `json:"sender_id,omitempty"`
+	ReceiptId       string `json:"receipt_id,omitempty"`
+	ReceiverId      string `json:"receiver_id,omitempty"`
+	LightClientHead string `json:"light_client_head"`
+}
+
+// ProofRequest identifies the outcome a light_client_proof result proves.
+type ProofRequest struct {
+	Type OutcomeType
+	// Transaction hash or receipt id
+	Id nearprimitive.CryptoHash
+	// Sender of the transaction or receiver of the receipt
+	AccountId       nearprimitive.AccountId
+	LightClientHead nearprimitive.CryptoHash
+}
+
+func (r NearProofRequest) parse() (ProofRequest, error) {
+	req := ProofRequest{}
+
+	var id, account_id string
+	switch r.Type {
+	case "transaction":
+		req.Type = TransactionOutcome
+		id, account_id = r.TransactionHash, r.SenderId
+	case "receipt":
+		req.Type = ReceiptOutcome
+		id, account_id = r.ReceiptId, r.ReceiverId
+	default:
+		return ProofRequest{}, fmt.Errorf("Unknown proof request type: %s", r.Type)
+	}
+
+	if account_id == "" {
+		return ProofRequest{}, fmt.Errorf("Missing account id in %s proof request", r.Type)
+	}
+
+	req.AccountId = nearprimitive.AccountId(account_id)
+
+	err := req.Id.TryFromRaw(base58.Decode(id))
+	if err != nil {
+		return ProofRequest{}, fmt.Errorf("Failed to decode %s id: %s", r.Type, err)
+	}
+
+	err = req.LightClientHead.TryFromRaw(base58.Decode(r.LightClientHead))
+	if err != nil {
+		return ProofRequest{}, fmt.Errorf("Failed to decode light client head: %w", err)
+	}
+
+	return req, nil
+}
+
+// GetProofRequest parses the params of a light_client_proof request
+func GetProofRequest(request string) (ProofRequest, error) {
+	r := NearProofRequest{}
+
+	err := json.Unmarshal([]byte(request), &r)
+	if err != nil {
+		return ProofRequest{}, fmt.Errorf("Failed to unmarshal proof request: %w", err)
+	}
+
+	return r.parse()
+}
+
 type TxRpcResponse struct {
 	Id      string   `json:"id"`
 	Jsonrpc string   `json:"jsonrpc"`
@@ -337,78 +401,99 @@ func GetTxProof(response string) (TxResult, error) {
 	return bp.Result, nil
 }
 
-// GetOutcomeProof will give outcome proof and outcome root proof from Rpc json response from near node
-func GetOutcomeProof(response string) (nearprimitive.OutcomeProof, []nearprimitive.MerklePathItem, nearprimitive.CryptoHash) {
-	bp := TxRpcResponse{}
+type BlockProofResult struct {
+	BlockHeaderLite BlockHeaderLite `json:"block_header_lite"`
+	BlockProof      Proof           `json:"block_proof"`
+}
 
-	err := json.Unmarshal([]byte(response), &bp)
+type NearBlockProof struct {
+	BlockHeaderLite nearprimitive.LightClientBlockLiteView
+	BlockProof      nearprimitive.MerklePath
+}
+
+func (bp BlockProofResult) parse() (NearBlockProof, error) {
+	lite_header, err := bp.BlockHeaderLite.parse()
 	if err != nil {
-		fmt.Printf("Failed to unmarshal RpcResponse: %s", err)
+		return NearBlockProof{}, fmt.Errorf("Failed to parse lite header: %w", err)
 	}
 
-	expected_outcome_root := &nearprimitive.CryptoHash{}
-	err = expected_outcome_root.TryFromRaw(base58.Decode(bp.Result.BlockHeaderLite.InnerLite.OutcomeRoot))
+	block_proof, err := bp.BlockProof.parse()
 	if err != nil {
-		fmt.Printf("Failed to get expected_outcome_root: %s", err)
+		return NearBlockProof{}, fmt.Errorf("Failed to parse block proof: %w", err)
 	}
 
-	receipt_ids := []nearprimitive.CryptoHash{}
-	single_receipt := nearprimitive.CryptoHash{}
+	return NearBlockProof{BlockHeaderLite: lite_header, BlockProof: block_proof}, nil
+}
 
-	for i := 0; i < len(bp.Result.OutcomeProof.Outcome.ReceiptIds); i++ {
-		err = single_receipt.TryFromRaw(base58.Decode(bp.Result.OutcomeProof.Outcome.ReceiptIds[i]))
-		receipt_ids = append(receipt_ids, single_receipt)
-		if err != nil {
-			fmt.Printf("Failed to get receipt Id: %s", err)
-		}
-	}
+type BlockProofRpcResponse struct {
+	Id      string           `json:"id"`
+	Jsonrpc string           `json:"jsonrpc"`
+	Result  BlockProofResult `json:"result"`
+}
 
-	token_burnt, _, _ := num.U128FromString(bp.Result.OutcomeProof.Outcome.TokensBurnt)
+// GetBlockProof parses an EXPERIMENTAL_light_client_block_proof Rpc json response
+func GetBlockProof(response string) (NearBlockProof, error) {
+	bp := BlockProofRpcResponse{}
 
-	serialized_status, err := nearprimitive.IntoExecutionStatusView(bp.Result.OutcomeProof.Outcome.Status)
+	err := json.Unmarshal([]byte(response), &bp)
 	if err != nil {
-		fmt.Printf("Failed to parse status: %s", err)
+		return NearBlockProof{}, fmt.Errorf("Failed to unmarshal RpcResponse: %w", err)
 	}
 
-	ser_status, err := borsh.Serialize(serialized_status)
+	return bp.Result.parse()
+}
+
+// GetNearTxResult parses a light_client_proof Rpc json response into its verifiable form
+func GetNearTxResult(response string) (NearTxResult, error) {
+	tx_result, err := GetTxProof(response)
 	if err != nil {
-		fmt.Printf("Failed to serialize status: %s", err)
+		return NearTxResult{}, err
 	}
 
-	execution_outcome := nearprimitive.ExecutionOutcomeView{
-		Logs:        bp.Result.OutcomeProof.Outcome.Logs,
-		ReceiptIds:  receipt_ids,
-		GasBurnt:    nearprimitive.Gas(bp.Result.OutcomeProof.Outcome.GasBurnt),
-		TokensBurnt: token_burnt,
-		ExecutorId:  nearprimitive.AccountId(bp.Result.OutcomeProof.Outcome.ExecutorId),
-		Status:      ser_status,
+	return tx_result.parse()
+}
+
+// GetOutcomeProof will give outcome proof and outcome root proof from Rpc json response from near node
+func GetOutcomeProof(response string) (nearprimitive.OutcomeProof, []nearprimitive.MerklePathItem, nearprimitive.CryptoHash, error) {
+	tx_result, err := GetNearTxResult(response)
+	if err != nil {
+		return nearprimitive.OutcomeProof{}, nil, nearprimitive.CryptoHash{}, err
 	}
 
-	id := &nearprimitive.CryptoHash{}
-	err = id.TryFromRaw(base58.Decode(bp.Result.OutcomeProof.Id))
+	return tx_result.OutcomeProof, tx_result.OutcomeRootProof, tx_result.BlockHeaderLite.InnerLite.OutcomeRoot, nil
+}
+
+func (bps NearNextBps) parse() (nearprimitive.ValidatorStakeView, error) {
+	version, err := nearprimitive.ParseValidatorStakeViewVersion(bps.ValidatorStakeStructVersion)
 	if err != nil {
-		fmt.Printf("Failed to decode Outcome Proof Id: %s", err)
+		return nearprimitive.ValidatorStakeView{}, fmt.Errorf("Failed to parse validator stake of %s: %w", bps.AccountId, err)
 	}
 
-	block_hash := &nearprimitive.CryptoHash{}
-	err = block_hash.TryFromRaw(base58.Decode(bp.Result.OutcomeProof.BlockHash))
-	err = id.TryFromRaw(base58.Decode(bp.Result.OutcomeProof.Id))
+	key_type, encoded_pub_key, found := strings.Cut(bps.PublicKey, ":")
+	if !found || key_type != "ed25519" {
+		return nearprimitive.ValidatorStakeView{}, fmt.Errorf("Unsupported public key %s of %s", bps.PublicKey, bps.AccountId)
+	}
 
+	pubkey := &nearprimitive.PublicKey{}
+	err = pubkey.TryFromRaw(base58.Decode(encoded_pub_key))
 	if err != nil {
-		fmt.Printf("Failed to Decode block Hash: %s", err)
+		return nearprimitive.ValidatorStakeView{}, fmt.Errorf("Failed to parse pub key of %s: %s", bps.AccountId, err)
 	}
 
-	outcome_proof := nearprimitive.OutcomeProof{
-		Proof:     GetProof(bp.Result.OutcomeProof.Proof),
-		BlockHash: *block_hash,
-		Id:        *id,
-		Outcome:   execution_outcome,
+	stake, err := nearprimitive.ParseBalance(bps.Stake)
+	if err != nil {
+		return nearprimitive.ValidatorStakeView{}, fmt.Errorf("Failed to parse stake of %s: %s", bps.AccountId, err)
+	}
+
+	switch version {
+	case nearprimitive.V1:
+		return nearprimitive.NewValidatorStakeViewV1(nearprimitive.AccountId(bps.AccountId), *pubkey, stake), nil
 	}
 
-	return outcome_proof, GetProof(bp.Result.OutcomeRootProof), *expected_outcome_root
+	return nearprimitive.ValidatorStakeView{}, fmt.Errorf("%w %v", nearprimitive.ErrUnknownValidatorStakeVersion, version)
 }
 
-func (n *NearLightClientBlockView) parse() nearprimitive.LightClientBlockView {
+func (n *NearLightClientBlockView) parse() (nearprimitive.LightClientBlockView, error) {
 	lb := nearprimitive.LightClientBlockView{}
 
 	// Parse the signatures
@@ -416,133 +501,51 @@ func (n *NearLightClientBlockView) parse() nearprimitive.LightClientBlockView {
 		if approval == nil {
 			lb.ApprovalsAfterNext = append(lb.ApprovalsAfterNext, nil)
 		} else {
-			// TODO: Improve error handling here
-			signature := strings.Split(*approval, ":")[1]
-			decode_sig := base58.Decode(signature)
+			_, signature, found := strings.Cut(*approval, ":")
+			if !found {
+				return nearprimitive.LightClientBlockView{}, fmt.Errorf("Ill-formed signature: %s", *approval)
+			}
 
 			sig := &nearprimitive.Signature{}
-			err := sig.TryFromRaw([]byte(decode_sig))
-
+			err := sig.TryFromRaw(base58.Decode(signature))
 			if err != nil {
-				fmt.Printf("Failed to decode signature: %s", err)
+				return nearprimitive.LightClientBlockView{}, fmt.Errorf("Failed to decode signature: %w", err)
 			}
+
 			lb.ApprovalsAfterNext = append(lb.ApprovalsAfterNext, sig)
 		}
 	}
 
-	// Parse InnerLightView
-	decoded_epoch_id := base58.Decode(n.Result.InnerLite.EpochId)
-	epoch_id := &nearprimitive.CryptoHash{}
-	err := epoch_id.TryFromRaw(decoded_epoch_id)
+	inner_lite, err := n.Result.InnerLite.parse()
 	if err != nil {
-		fmt.Printf("Failed to decode epoch id: %s", err)
+		return nearprimitive.LightClientBlockView{}, fmt.Errorf("Failed to parse inner lite: %w", err)
 	}
 
-	decoded_next_epoch_id := base58.Decode(n.Result.InnerLite.NextEpochId)
-	next_epoch_id := &nearprimitive.CryptoHash{}
-	err = next_epoch_id.TryFromRaw(decoded_next_epoch_id)
-	if err != nil {
-		fmt.Printf("Failed to decode epoch id: %s", err)
-	}
-
-	decoded_prev_state := base58.Decode(n.Result.InnerLite.PrevStateRoot)
-	prev_state := &nearprimitive.CryptoHash{}
-	err = prev_state.TryFromRaw(decoded_prev_state)
-	if err != nil {
-		fmt.Printf("Failed to decode epoch id: %s", err)
-	}
+	lb.InnerLite = inner_lite
 
-	decoded_outcome_root := base58.Decode(n.Result.InnerLite.OutcomeRoot)
-	outcome_root := &nearprimitive.CryptoHash{}
-	err = outcome_root.TryFromRaw(decoded_outcome_root)
+	err = lb.PrevBlockHash.TryFromRaw(base58.Decode(n.Result.PrevBlockHash))
 	if err != nil {
-		fmt.Printf("Failed to decode epoch id: %s", err)
+		return nearprimitive.LightClientBlockView{}, fmt.Errorf("Failed to decode prev block hash: %w", err)
 	}
 
-	decoded_next_bp_hash := base58.Decode(n.Result.InnerLite.NextBpHash)
-	next_bp_hash := &nearprimitive.CryptoHash{}
-	err = next_bp_hash.TryFromRaw(decoded_next_bp_hash)
-	if err != nil {
-		fmt.Printf("Failed to decode epoch id: %s", err)
-	}
-
-	decoded_block_merkle_root := base58.Decode(n.Result.InnerLite.BlockMerkleRoot)
-	block_merkle_root := &nearprimitive.CryptoHash{}
-	err = block_merkle_root.TryFromRaw(decoded_block_merkle_root)
+	err = lb.NextBlockInnerHash.TryFromRaw(base58.Decode(n.Result.NextBlockInnerHash))
 	if err != nil {
-		fmt.Printf("Failed to decode epoch id: %s", err)
+		return nearprimitive.LightClientBlockView{}, fmt.Errorf("Failed to decode next block inner hash: %w", err)
 	}
 
-	timestamp_nanosec, err := strconv.ParseUint(n.Result.InnerLite.TimestampNanosec, 10, 64)
+	err = lb.InnerRestHash.TryFromRaw(base58.Decode(n.Result.InnerRestHash))
 	if err != nil {
-		fmt.Printf("Failed to parse timestamp nanosec: %s", err)
+		return nearprimitive.LightClientBlockView{}, fmt.Errorf("Failed to decode inner rest hash: %w", err)
 	}
 
-	lb.InnerLite = nearprimitive.BlockHeaderInnerLiteView{
-		Height:           nearprimitive.BlockHeight(n.Result.InnerLite.Height),
-		EpochId:          *epoch_id,
-		NextEpochId:      *next_epoch_id,
-		PrevStateRoot:    *prev_state,
-		BlockMerkleRoot:  *block_merkle_root,
-		NextBpHash:       *next_bp_hash,
-		OutcomeRoot:      *outcome_root,
-		Timestamp:        timestamp_nanosec,
-		TimestampNanosec: timestamp_nanosec,
-	}
-
-	// Parse previous block hash
-	decoded_prev_block_hash := base58.Decode(n.Result.PrevBlockHash)
-	prev_block_hash := &nearprimitive.CryptoHash{}
-	err = prev_block_hash.TryFromRaw(decoded_prev_block_hash)
-	if err != nil {
-		fmt.Printf("Failed to decode epoch id: %s", err)
-	}
-
-	lb.PrevBlockHash = *prev_block_hash
-
-	// Parse next block hash
-	decoded_next_block_inner_hash := base58.Decode(n.Result.NextBlockInnerHash)
-	next_block_inner_hash := &nearprimitive.CryptoHash{}
-	err = next_block_inner_hash.TryFromRaw(decoded_next_block_inner_hash)
-	if err != nil {
-		fmt.Printf("Failed to decode epoch id: %s", err)
-	}
-
-	lb.NextBlockInnerHash = *next_block_inner_hash
-
-	// Parse inner rest hash
-	decoded_inner_rest_hash := base58.Decode(n.Result.InnerRestHash)
-	inner_rest_hash := &nearprimitive.CryptoHash{}
-	err = inner_rest_hash.TryFromRaw(decoded_inner_rest_hash)
-	if err != nil {
-		fmt.Printf("Failed to decode epoch id: %s", err)
-	}
-
-	lb.InnerRestHash = *inner_rest_hash
-
-	// Parse next BPS
 	for _, bps := range n.Result.NextBps {
-		vs := nearprimitive.ValidatorStakeView{}
-		if bps.ValidatorStakeStructVersion == "V1" {
-			vs.Version = nearprimitive.V1
-		}
-
-		vs.V1.AccountId = nearprimitive.AccountId(bps.AccountId)
-
-		encoded_pub_key := strings.Split(bps.PublicKey, ":")[1]
-		decoded_pub_key := base58.Decode(encoded_pub_key)
-
-		pubkey := &nearprimitive.PublicKey{}
-		err := pubkey.TryFromRaw(decoded_pub_key)
+		vs, err := bps.parse()
 		if err != nil {
-			fmt.Printf("Failed to parse pub key: %s", err)
+			return nearprimitive.LightClientBlockView{}, fmt.Errorf("Failed to parse next bps: %w", err)
 		}
-		vs.V1.PublicKey = *pubkey
-
-		vs.V1.Stake, _, _ = num.U128FromString(bps.Stake)
 
 		lb.NextBps = append(lb.NextBps, vs)
 	}
 
-	return lb
+	return lb, nil
 }
@@ -3,10 +3,480 @@
 package light
 
 import (
+	"fmt"
+	"sync"
+
 	"github.com/electron-labs/near-light-client-go/nearprimitive"
 )
 
 type NearLightClientInterface interface {
-	NewFromCheckpoint(checkpoint nearprimitive.LightClientBlockView, heights_to_track uint64)
+	NewFromCheckpoint(checkpoint nearprimitive.LightClientBlockView, heights_to_track uint64) error
 	CurrentBlockHeight() uint64
 }
+
+// LightClient keeps the trusted head and the block producers of every epoch
+// it has learned about, and advances the head as new light client blocks are
+// validated.
+//
+// A LightClient is safe for concurrent use: proofs can be verified from many
+// goroutines while another one advances the head.
+type LightClient struct {
+	host nearprimitive.HostFunction
+
+	// mu guards the head, the tracked heads, epochs and conflicts
+	mu                   sync.RWMutex
+	head                 nearprimitive.LightClientBlockView
+	head_hash            nearprimitive.CryptoHash
+	history              *HeadHistory
+	epochs               *EpochRegistry
+	epoch_pruning_policy EpochPruningPolicy
+	store                Store
+	conflicts            *ConflictTracker
+	conflict_window      int
+	verify_workers       int
+	validate_opts        []ValidateOption
+
+	// emit_mu guards pending and dispatching. Events are queued under mu, so
+	// subscribers see them in update order
+	emit_mu              sync.Mutex
+	pending              []Event
+	dispatching          bool
+	subscriptions_mu     sync.Mutex
+	subscriptions        []subscription
+	next_subscription_id uint64
+}
+
+var _ NearLightClientInterface = (*LightClient)(nil)
+
+type ClientOption func(*LightClient)
+
+// WithEpochPruningPolicy overrides DefaultEpochsToKeep.
+func WithEpochPruningPolicy(policy EpochPruningPolicy) ClientOption {
+	return func(lc *LightClient) {
+		lc.epoch_pruning_policy = policy
+	}
+}
+
+// WithStore persists the client state to store after every head update.
+func WithStore(store Store) ClientOption {
+	return func(lc *LightClient) {
+		lc.store = store
+	}
+}
+
+// WithConflictWindow overrides DefaultConflictWindow.
+func WithConflictWindow(window int) ClientOption {
+	return func(lc *LightClient) {
+		lc.conflict_window = window
+	}
+}
+
+// WithVerifyWorkers verifies the approvals of every block across n
+// goroutines, see WithWorkers.
+func WithVerifyWorkers(n int) ClientOption {
+	return func(lc *LightClient) {
+		lc.verify_workers = n
+	}
+}
+
+// WithValidateOptions applies opts to the validation of every block, e.g. a
+// WithPolicy.
+func WithValidateOptions(opts ...ValidateOption) ClientOption {
+	return func(lc *LightClient) {
+		lc.validate_opts = append(lc.validate_opts, opts...)
+	}
+}
+
+func NewLightClient(h nearprimitive.HostFunction, opts ...ClientOption) *LightClient {
+	lc := &LightClient{
+		host:                 h,
+		history:              NewHeadHistory(1),
+		epoch_pruning_policy: DefaultEpochsToKeep,
+		conflict_window:      DefaultConflictWindow,
+		verify_workers:       1,
+	}
+
+	for _, opt := range opts {
+		opt(lc)
+	}
+
+	lc.epochs = NewEpochRegistry(lc.epoch_pruning_policy)
+	lc.conflicts = NewConflictTracker(lc.conflict_window)
+
+	return lc
+}
+
+// NewFromCheckpoint is Bootstrap.
+func (lc *LightClient) NewFromCheckpoint(checkpoint nearprimitive.LightClientBlockView, heights_to_track uint64) error {
+	return lc.Bootstrap(checkpoint, heights_to_track)
+}
+
+// Bootstrap trusts the checkpoint as the head and records its next block
+// producers under its next epoch id. The last heights_to_track accepted heads
+// are kept so that proofs built against a recent, but no longer current, head
+// can still be verified.
+func (lc *LightClient) Bootstrap(checkpoint nearprimitive.LightClientBlockView, heights_to_track uint64) error {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	checkpoint_hash, err := checkpoint.CurrentBlockHash(lc.host)
+	if err != nil {
+		return fmt.Errorf("Failed to get checkpoint hash: %w", err)
+	}
+
+	history := NewHeadHistory(heights_to_track)
+	epochs := NewEpochRegistry(lc.epoch_pruning_policy)
+
+	err = epochs.InsertFromBlock(lc.host, checkpoint)
+	if err != nil {
+		return fmt.Errorf("Failed to record checkpoint block producers: %w", err)
+	}
+
+	history.Add(checkpoint_hash, checkpoint)
+
+	err = lc.persist(new_state(checkpoint, history, epochs, nil))
+	if err != nil {
+		return err
+	}
+
+	lc.history = history
+	lc.epochs = epochs
+	lc.conflicts = NewConflictTracker(lc.conflict_window)
+	lc.head = checkpoint
+	lc.head_hash = checkpoint_hash
+
+	return nil
+}
+
+// Resume restores the state last saved to the client's store. It returns
+// false if the store is empty, in which case the client has to be
+// bootstrapped from a checkpoint.
+func (lc *LightClient) Resume() (bool, error) {
+	if lc.store == nil {
+		return false, fmt.Errorf("No store configured")
+	}
+
+	state, err := lc.store.Load()
+	if err != nil {
+		return false, fmt.Errorf("Failed to load state: %w", err)
+	}
+
+	if state == nil {
+		return false, nil
+	}
+
+	lc.mu.Lock()
+	err = lc.restore(state)
+	lc.mu.Unlock()
+	if err != nil {
+		return false, fmt.Errorf("Failed to restore state: %w", err)
+	}
+
+	return true, nil
+}
+
+func (lc *LightClient) restore(state *LightClientState) error {
+	history := NewHeadHistory(state.HeightsToTrack)
+	for _, head := range state.HeadHistory {
+		head_hash, err := head.CurrentBlockHash(lc.host)
+		if err != nil {
+			return fmt.Errorf("Failed to get head hash: %w", err)
+		}
+
+		history.Add(head_hash, head)
+	}
+
+	epochs := NewEpochRegistry(lc.epoch_pruning_policy)
+	for _, epoch := range state.Epochs {
+		err := epochs.Insert(lc.host, epoch.EpochId, epoch.NextBpHash, epoch.BlockProducers)
+		if err != nil {
+			return fmt.Errorf("Failed to restore epoch %v: %s", epoch.EpochId, err)
+		}
+	}
+
+	head_hash, err := state.Head.CurrentBlockHash(lc.host)
+	if err != nil {
+		return fmt.Errorf("Failed to get head hash: %w", err)
+	}
+
+	history.Add(head_hash, state.Head)
+
+	lc.history = history
+	lc.epochs = epochs
+	lc.conflicts = NewConflictTracker(lc.conflict_window)
+	lc.conflicts.evidence = state.Evidence
+	lc.head = state.Head
+	lc.head_hash = head_hash
+
+	return nil
+}
+
+// State returns a snapshot of the client state.
+func (lc *LightClient) State() *LightClientState {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+
+	return lc.state()
+}
+
+func (lc *LightClient) state() *LightClientState {
+	return new_state(lc.head, lc.history, lc.epochs, lc.conflicts.Evidence())
+}
+
+func new_state(head nearprimitive.LightClientBlockView, history *HeadHistory, epochs *EpochRegistry, evidence *EquivocationEvidence) *LightClientState {
+	state := &LightClientState{
+		Head:           head,
+		HeightsToTrack: history.Capacity(),
+		HeadHistory:    history.Heads(),
+		Epochs:         []EpochState{},
+		Evidence:       evidence,
+	}
+
+	for _, epoch_id := range epochs.EpochIds() {
+		entry := epochs.epochs[epoch_id]
+		state.Epochs = append(state.Epochs, EpochState{
+			EpochId:        epoch_id,
+			NextBpHash:     entry.next_bp_hash,
+			BlockProducers: entry.block_producers,
+		})
+	}
+
+	return state
+}
+
+func (lc *LightClient) persist(state *LightClientState) error {
+	if lc.store == nil {
+		return nil
+	}
+
+	err := lc.store.Save(state)
+	if err != nil {
+		return fmt.Errorf("Failed to save state: %w", err)
+	}
+
+	return nil
+}
+
+func (lc *LightClient) CurrentBlockHeight() uint64 {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+
+	return uint64(lc.head.InnerLite.Height)
+}
+
+func (lc *LightClient) Head() nearprimitive.LightClientBlockView {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+
+	return lc.head
+}
+
+func (lc *LightClient) HeadHash() nearprimitive.CryptoHash {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+
+	return lc.head_hash
+}
+
+// HeadSnapshot returns the head together with its hash. Unlike calling Head
+// and HeadHash one after the other, both are guaranteed to belong to the same
+// head while another goroutine updates the client.
+func (lc *LightClient) HeadSnapshot() (nearprimitive.CryptoHash, nearprimitive.LightClientBlockView) {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+
+	return lc.head_hash, lc.head
+}
+
+// HeadByHash returns a previously accepted head that is still tracked.
+func (lc *LightClient) HeadByHash(block_hash nearprimitive.CryptoHash) (nearprimitive.LightClientBlockView, bool) {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+
+	return lc.history.GetByHash(block_hash)
+}
+
+// HeadByHeight returns a previously accepted head that is still tracked.
+func (lc *LightClient) HeadByHeight(height nearprimitive.BlockHeight) (nearprimitive.LightClientBlockView, bool) {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+
+	return lc.history.GetByHeight(height)
+}
+
+func (lc *LightClient) EpochBlockProducers(epoch_id nearprimitive.CryptoHash) ([]nearprimitive.ValidatorStakeView, error) {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+
+	return lc.epochs.BlockProducers(epoch_id)
+}
+
+// Evidence returns the equivocation that halted the client, if any.
+func (lc *LightClient) Evidence() *EquivocationEvidence {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+
+	return lc.conflicts.Evidence()
+}
+
+// ValidateAndUpdateHead validates block_view against the current head and, on
+// success, makes it the new head. The new head is only adopted once it has
+// been saved to the client's store. Once conflicting blocks have been seen the
+// client refuses every update with a ConflictError.
+//
+// Approvals are verified without holding the client's lock, so proofs can be
+// verified and heads looked up while a block is being validated.
+func (lc *LightClient) ValidateAndUpdateHead(block_view nearprimitive.LightClientBlockView) error {
+	lc.mu.RLock()
+	evidence := lc.conflicts.Evidence()
+	epochs := lc.epochs
+	lc.mu.RUnlock()
+
+	if evidence != nil {
+		return &ConflictError{Evidence: evidence}
+	}
+
+	opts := append([]ValidateOption{WithWorkers(lc.verify_workers)}, lc.validate_opts...)
+	config := new_validate_config(opts)
+
+	// The registry is replaced, never modified, on updates. An epoch's block
+	// producers never change once recorded, so the result holds whatever head
+	// the client has moved to meanwhile.
+	verified, verify_err := verify_light_block(lc.host, &block_view, epochs, config)
+
+	lc.mu.Lock()
+	events, err := lc.update_head(block_view, verified, verify_err, config)
+	lc.queue(events)
+	lc.mu.Unlock()
+
+	lc.dispatch()
+
+	return err
+}
+
+func (lc *LightClient) update_head(block_view nearprimitive.LightClientBlockView, verified verified_block, verify_err error, config validate_config) ([]Event, error) {
+	// Another update may have halted the client while approvals were verified
+	if evidence := lc.conflicts.Evidence(); evidence != nil {
+		return nil, &ConflictError{Evidence: evidence}
+	}
+
+	if verify_err != nil {
+		err := fmt.Errorf("Failed to validate light block: %w", verify_err)
+		return []Event{ValidationFailedEvent{Err: err, Block: block_view}}, err
+	}
+
+	evidence := lc.conflicts.observe(block_view, verified)
+	if evidence != nil {
+		lc.conflicts.evidence = evidence
+		conflict_err := &ConflictError{Evidence: evidence}
+
+		// Halt across restarts too
+		events := []Event{ConflictDetectedEvent{Evidence: evidence}}
+		err := lc.persist(lc.state())
+		if err != nil {
+			return events, fmt.Errorf("%w; %s", conflict_err, err)
+		}
+
+		return events, conflict_err
+	}
+
+	// Checked against the head as it is now, not as it was before verifying
+	err := check_block_against_head(&lc.head, &block_view, config)
+	if err == nil {
+		err = check_policy(config, &lc.head, &block_view, verified.report)
+	}
+	if err != nil {
+		err = fmt.Errorf("Failed to validate light block: %w", err)
+		return []Event{ValidationFailedEvent{Err: err, Block: block_view}}, err
+	}
+
+	block_hash := verified.block_hash
+
+	// Staged on copies, the client is left untouched if saving fails
+	history := lc.history.clone()
+	epochs := lc.epochs.clone()
+
+	events := []Event{}
+
+	if len(block_view.NextBps) > 0 {
+		new_epoch := !epochs.Contains(block_view.InnerLite.NextEpochId)
+
+		err = epochs.InsertFromBlock(lc.host, block_view)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to record next block producers: %w", err)
+		}
+
+		if new_epoch {
+			events = append(events, EpochTransitionEvent{
+				EpochId:   block_view.InnerLite.NextEpochId,
+				NextBps:   block_view.NextBps,
+				BlockHash: block_hash,
+				Height:    block_view.InnerLite.Height,
+			})
+		}
+	}
+
+	history.Add(block_hash, block_view)
+
+	err = lc.persist(new_state(block_view, history, epochs, lc.conflicts.Evidence()))
+	if err != nil {
+		return nil, err
+	}
+
+	lc.head = block_view
+	lc.head_hash = block_hash
+	lc.history = history
+	lc.epochs = epochs
+
+	return append(events, NewHeadEvent{BlockHash: block_hash, Head: block_view}), nil
+}
+
+// VerifyTransactionProof checks a light_client_proof result against the
+// tracked head it was requested for (light_client_head) with VerifyOutcome.
+// The head is looked up once, verification itself runs without blocking head
+// updates.
+func (lc *LightClient) VerifyTransactionProof(light_client_head nearprimitive.CryptoHash, proof NearTxResult) (VerifiedOutcome, error) {
+	return lc.verify_outcome(light_client_head, proof)
+}
+
+// VerifyReceiptProof is VerifyTransactionProof for the outcome of receipt_id,
+// which must have been executed by receiver_id.
+func (lc *LightClient) VerifyReceiptProof(light_client_head nearprimitive.CryptoHash, receipt_id nearprimitive.CryptoHash, receiver_id nearprimitive.AccountId, proof NearTxResult) (VerifiedOutcome, error) {
+	err := check_receipt_outcome(proof.OutcomeProof, receipt_id, receiver_id)
+	if err != nil {
+		return VerifiedOutcome{}, fmt.Errorf("Failed to validate receipt: %w", err)
+	}
+
+	return lc.verify_outcome(light_client_head, proof)
+}
+
+// VerifyProof verifies a light_client_proof result against the request it
+// answers: the outcome must have the requested id and have been executed by
+// the requested sender or receiver.
+func (lc *LightClient) VerifyProof(request ProofRequest, proof NearTxResult) (VerifiedOutcome, error) {
+	switch request.Type {
+	case TransactionOutcome:
+		if proof.OutcomeProof.Id != request.Id {
+			return VerifiedOutcome{}, fmt.Errorf("%w: expected transaction %v, got %v", ErrOutcomeIdMismatch, request.Id, proof.OutcomeProof.Id)
+		}
+
+		if proof.OutcomeProof.Outcome.ExecutorId != request.AccountId {
+			return VerifiedOutcome{}, fmt.Errorf("%w: expected %s, got %s", ErrExecutorMismatch, request.AccountId, proof.OutcomeProof.Outcome.ExecutorId)
+		}
+
+		return lc.VerifyTransactionProof(request.LightClientHead, proof)
+	case ReceiptOutcome:
+		return lc.VerifyReceiptProof(request.LightClientHead, request.Id, request.AccountId, proof)
+	}
+
+	return VerifiedOutcome{}, fmt.Errorf("Unknown outcome type %d", request.Type)
+}
+
+func (lc *LightClient) verify_outcome(light_client_head nearprimitive.CryptoHash, proof NearTxResult) (VerifiedOutcome, error) {
+	head, ok := lc.HeadByHash(light_client_head)
+	if !ok {
+		return VerifiedOutcome{}, fmt.Errorf("Light client head %v is not tracked", light_client_head)
+	}
+
+	return VerifyOutcome(lc.host, head, proof)
+}
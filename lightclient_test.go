@@ -0,0 +1,306 @@
+// Copyright © 2022, Electron Labs
+
+package light
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	base58 "github.com/btcsuite/btcutil/base58"
+	"github.com/electron-labs/near-light-client-go/mock"
+	"github.com/electron-labs/near-light-client-go/nearprimitive"
+)
+
+func TestLightClientValidateAndUpdateHead(t *testing.T) {
+	pre_epoch, err := GetClientBlockView(CLIENT_RESPONSE_PREVIOUS_EPOCH)
+	if err != nil {
+		t.Errorf("Failed to parse prev client block: %s", err)
+	}
+
+	curr_epoch, err := GetClientBlockView(CLIENT_BLOCK_RESPONSE)
+	if err != nil {
+		t.Errorf("Failed to parse current client block: %s", err)
+	}
+
+	next_epoch, err := GetClientBlockView(CLIENT_BLOCK_RESPONSE_NEXT_BLOCK)
+	if err != nil {
+		t.Errorf("Failed to parse next client block: %s", err)
+	}
+
+	lc := NewLightClient(mock.MockHostFunction{})
+	err = lc.NewFromCheckpoint(pre_epoch, 10)
+	if err != nil {
+		t.Fatalf("Failed to bootstrap: %s", err)
+	}
+
+	if lc.CurrentBlockHeight() != uint64(pre_epoch.InnerLite.Height) {
+		t.Errorf("Unexpected height after checkpoint: %d", lc.CurrentBlockHeight())
+	}
+
+	err = lc.ValidateAndUpdateHead(curr_epoch)
+	if err != nil {
+		t.Errorf("Failed to validate block: %s", err)
+	}
+
+	if lc.CurrentBlockHeight() != uint64(curr_epoch.InnerLite.Height) {
+		t.Errorf("Head was not advanced: %d", lc.CurrentBlockHeight())
+	}
+
+	if _, err := lc.EpochBlockProducers(curr_epoch.InnerLite.NextEpochId); err != nil {
+		t.Errorf("Next block producers were not recorded")
+	}
+
+	err = lc.ValidateAndUpdateHead(next_epoch)
+	if err != nil {
+		t.Errorf("Failed to validate block: %s", err)
+	}
+
+	// previous block should fail and leave the head untouched
+	err = lc.ValidateAndUpdateHead(curr_epoch)
+	if err == nil {
+		t.Errorf("Block verification succeded but it should not!!!")
+	}
+
+	if lc.CurrentBlockHeight() != uint64(next_epoch.InnerLite.Height) {
+		t.Errorf("Head moved after a failed update: %d", lc.CurrentBlockHeight())
+	}
+}
+
+func TestLightClientRejectsBadCheckpoint(t *testing.T) {
+	pre_epoch, err := GetClientBlockView(CLIENT_RESPONSE_PREVIOUS_EPOCH)
+	if err != nil {
+		t.Fatalf("Failed to parse prev client block: %s", err)
+	}
+
+	// The next block producers no longer match next_bp_hash
+	pre_epoch.NextBps = pre_epoch.NextBps[1:]
+
+	lc := NewLightClient(mock.MockHostFunction{})
+	err = lc.NewFromCheckpoint(pre_epoch, 10)
+	if err == nil {
+		t.Fatalf("Bootstrapped from a checkpoint with invalid block producers")
+	}
+
+	if lc.CurrentBlockHeight() != 0 {
+		t.Errorf("Checkpoint became the head: %d", lc.CurrentBlockHeight())
+	}
+}
+
+func TestLightClientTracksOlderHeads(t *testing.T) {
+	pre_epoch, err := GetClientBlockView(CLIENT_RESPONSE_PREVIOUS_EPOCH)
+	if err != nil {
+		t.Errorf("Failed to parse prev client block: %s", err)
+	}
+
+	curr_epoch, err := GetClientBlockView(CLIENT_BLOCK_RESPONSE)
+	if err != nil {
+		t.Errorf("Failed to parse current client block: %s", err)
+	}
+
+	next_epoch, err := GetClientBlockView(CLIENT_BLOCK_RESPONSE_NEXT_BLOCK)
+	if err != nil {
+		t.Errorf("Failed to parse next client block: %s", err)
+	}
+
+	h := mock.MockHostFunction{}
+	pre_epoch_hash, err := pre_epoch.CurrentBlockHash(h)
+	if err != nil {
+		t.Errorf("Failed to hash checkpoint: %s", err)
+	}
+
+	lc := NewLightClient(h)
+	err = lc.NewFromCheckpoint(pre_epoch, 2)
+	if err != nil {
+		t.Fatalf("Failed to bootstrap: %s", err)
+	}
+
+	err = lc.ValidateAndUpdateHead(curr_epoch)
+	if err != nil {
+		t.Errorf("Failed to validate block: %s", err)
+	}
+
+	if _, ok := lc.HeadByHash(pre_epoch_hash); !ok {
+		t.Errorf("Checkpoint should still be tracked")
+	}
+
+	err = lc.ValidateAndUpdateHead(next_epoch)
+	if err != nil {
+		t.Errorf("Failed to validate block: %s", err)
+	}
+
+	if _, ok := lc.HeadByHash(pre_epoch_hash); ok {
+		t.Errorf("Checkpoint should have been evicted")
+	}
+
+	if _, ok := lc.HeadByHeight(curr_epoch.InnerLite.Height); !ok {
+		t.Errorf("Previous head should still be tracked")
+	}
+}
+
+func TestLightClientVerifyTransactionProof(t *testing.T) {
+	head, err := GetClientBlockView(LIGHT_CLIENT_BLOCK)
+	if err != nil {
+		t.Errorf("Failed to parse light client block: %s", err)
+	}
+
+	proof, err := GetNearTxResult(EXECUTION_OUTCOME)
+	if err != nil {
+		t.Errorf("Failed to parse proof: %s", err)
+	}
+
+	h := mock.MockHostFunction{}
+	head_hash, err := head.CurrentBlockHash(h)
+	if err != nil {
+		t.Errorf("Failed to hash head: %s", err)
+	}
+
+	lc := NewLightClient(h)
+	err = lc.NewFromCheckpoint(head, 4)
+	if err != nil {
+		t.Fatalf("Failed to bootstrap: %s", err)
+	}
+
+	_, err = lc.VerifyTransactionProof(head_hash, proof)
+	if err != nil {
+		t.Errorf("Failed to verify proof: %s", err)
+	}
+
+	_, err = lc.VerifyTransactionProof(nearprimitive.CryptoHash{}, proof)
+	if err == nil {
+		t.Errorf("Proof verified against an unknown head")
+	}
+}
+
+func TestLightClientVerifyReceiptProof(t *testing.T) {
+	head, err := GetClientBlockView(LIGHT_CLIENT_BLOCK)
+	if err != nil {
+		t.Fatalf("Failed to parse light client block: %s", err)
+	}
+
+	head.InnerLite.BlockMerkleRoot.TryFromRaw(base58.Decode(RECEIPT_OUTCOME_BLOCK_MERKLE_ROOT))
+
+	proof, err := GetNearTxResult(RECEIPT_OUTCOME)
+	if err != nil {
+		t.Fatalf("Failed to parse proof: %s", err)
+	}
+
+	tx_proof, err := GetNearTxResult(EXECUTION_OUTCOME)
+	if err != nil {
+		t.Fatalf("Failed to parse transaction proof: %s", err)
+	}
+
+	h := mock.MockHostFunction{}
+	head_hash, err := head.CurrentBlockHash(h)
+	if err != nil {
+		t.Fatalf("Failed to hash head: %s", err)
+	}
+
+	lc := NewLightClient(h)
+	err = lc.NewFromCheckpoint(head, 4)
+	if err != nil {
+		t.Fatalf("Failed to bootstrap: %s", err)
+	}
+
+	request, err := GetProofRequest(fmt.Sprintf(`{"type": "receipt", "receipt_id": "AYDomG3TpmssBCp2F15qcos9CtharnDHHefKdxToH8Uf", "receiver_id": "receiver.testnet", "light_client_head": "%s"}`, base58.Encode(head_hash[:])))
+	if err != nil {
+		t.Fatalf("Failed to parse proof request: %s", err)
+	}
+
+	if request.Type != ReceiptOutcome || request.Id != proof.OutcomeProof.Id || request.LightClientHead != head_hash {
+		t.Errorf("Unexpected proof request: %v", request)
+	}
+
+	// The receipt is the one the transaction created
+	if tx_proof.OutcomeProof.Outcome.Status.SuccessReceiptId.Inner != request.Id {
+		t.Errorf("Receipt %v was not created by the transaction", request.Id)
+	}
+
+	outcome, err := lc.VerifyProof(request, proof)
+	if err != nil {
+		t.Errorf("Failed to verify receipt proof: %s", err)
+	}
+
+	if outcome.Id() != request.Id || outcome.ExecutorId() != request.AccountId {
+		t.Errorf("Unexpected outcome %v executed by %s", outcome.Id(), outcome.ExecutorId())
+	}
+
+	_, err = lc.VerifyReceiptProof(head_hash, request.Id, tx_proof.OutcomeProof.Outcome.ExecutorId, proof)
+	if !errors.Is(err, ErrExecutorMismatch) {
+		t.Errorf("Expected ErrExecutorMismatch, got %v", err)
+	}
+
+	_, err = lc.VerifyReceiptProof(head_hash, tx_proof.OutcomeProof.Id, request.AccountId, proof)
+	if !errors.Is(err, ErrOutcomeIdMismatch) {
+		t.Errorf("Expected ErrOutcomeIdMismatch, got %v", err)
+	}
+
+	// A transaction outcome is not a receipt outcome
+	_, err = lc.VerifyProof(ProofRequest{Type: ReceiptOutcome, Id: tx_proof.OutcomeProof.Id, AccountId: tx_proof.OutcomeProof.Outcome.ExecutorId, LightClientHead: head_hash}, proof)
+	if !errors.Is(err, ErrOutcomeIdMismatch) {
+		t.Errorf("Expected ErrOutcomeIdMismatch, got %v", err)
+	}
+
+	for _, r := range []string{
+		`{"type": "block", "light_client_head": "11111111111111111111111111111111"}`,
+		`{"type": "receipt", "receipt_id": "11111111111111111111111111111111", "light_client_head": "11111111111111111111111111111111"}`,
+		`{"type": "transaction", "transaction_hash": "1", "sender_id": "a.near", "light_client_head": "11111111111111111111111111111111"}`,
+	} {
+		_, err = GetProofRequest(r)
+		if err == nil {
+			t.Errorf("Parsed invalid proof request %s", r)
+		}
+	}
+}
+
+func TestLightClientVerifyTransactionProofRequest(t *testing.T) {
+	head, err := GetClientBlockView(LIGHT_CLIENT_BLOCK)
+	if err != nil {
+		t.Fatalf("Failed to parse light client block: %s", err)
+	}
+
+	proof, err := GetNearTxResult(EXECUTION_OUTCOME)
+	if err != nil {
+		t.Fatalf("Failed to parse proof: %s", err)
+	}
+
+	h := mock.MockHostFunction{}
+	head_hash, err := head.CurrentBlockHash(h)
+	if err != nil {
+		t.Fatalf("Failed to hash head: %s", err)
+	}
+
+	lc := NewLightClient(h)
+	err = lc.NewFromCheckpoint(head, 4)
+	if err != nil {
+		t.Fatalf("Failed to bootstrap: %s", err)
+	}
+
+	request, err := GetProofRequest(fmt.Sprintf(`{"type": "transaction", "transaction_hash": "89aUfq2SU6ktdjtvU6kTCtsueQomgZ7s3dCdoHDZgfrd", "sender_id": "partht.testnet", "light_client_head": "%s"}`, base58.Encode(head_hash[:])))
+	if err != nil {
+		t.Fatalf("Failed to parse proof request: %s", err)
+	}
+
+	if request.Type != TransactionOutcome || request.Id != proof.OutcomeProof.Id || request.LightClientHead != head_hash {
+		t.Errorf("Unexpected proof request: %v", request)
+	}
+
+	_, err = lc.VerifyProof(request, proof)
+	if err != nil {
+		t.Errorf("Failed to verify transaction proof: %s", err)
+	}
+
+	other_sender := request
+	other_sender.AccountId = "other.testnet"
+	_, err = lc.VerifyProof(other_sender, proof)
+	if !errors.Is(err, ErrExecutorMismatch) {
+		t.Errorf("Expected ErrExecutorMismatch, got %v", err)
+	}
+
+	other_id := request
+	other_id.Id = nearprimitive.CryptoHash{}
+	_, err = lc.VerifyProof(other_id, proof)
+	if !errors.Is(err, ErrOutcomeIdMismatch) {
+		t.Errorf("Expected ErrOutcomeIdMismatch, got %v", err)
+	}
+}
@@ -2,6 +2,8 @@ package light
 
 import (
 	"testing"
+
+	"github.com/electron-labs/near-light-client-go/mock"
 )
 
 const (
@@ -603,10 +605,158 @@ const (
 		}
 	  }
 	`
+
+	// Outcome of the receipt created by EXECUTION_OUTCOME's transaction,
+	// executed by another account in the following block. Built with the
+	// client's own hashing rather than captured from a node, it proves
+	// against RECEIPT_OUTCOME_BLOCK_MERKLE_ROOT.
+	RECEIPT_OUTCOME = `
+	{
+		"id": "dontcare",
+		"jsonrpc": "2.0",
+		"result": {
+		  "block_header_lite": {
+			"inner_lite": {
+			  "block_merkle_root": "7vPXGdNa4mSSbSYQUvGq4UjyMvUbmGCW7kgz5VrA2CbC",
+			  "epoch_id": "EeC8QHiPSdr6CSDhJiCQL4wMR8or33qvkvirzh9Moe6x",
+			  "height": 102367481,
+			  "next_bp_hash": "74P742gjuiU6UTxpzgPR1L4c1iqMu6ZtPxFj656XAyCx",
+			  "next_epoch_id": "4Wu9U6C3P9KAAymDYo5W5hv11yi7Xgw6UnyFS6u8V4T9",
+			  "outcome_root": "AXMd6xnqKetsFPKCgxYzP2VEcvTuUHwHuN9KkaiVoedU",
+			  "prev_state_root": "3QcxNqhDHW3XkjVe7bUbn6YkFRJxyXtQnsXSixaQkdWb",
+			  "timestamp": 1665413020184126035,
+			  "timestamp_nanosec": "1665413020184126035"
+			},
+			"inner_rest_hash": "5oWPLnzyGSQvkEJwxrz3zqKLVVbDTL7fz5uyEvKMmBrj",
+			"prev_block_hash": "EdnpBxt2QyAHvseR8whrbCZXipor8VaQckLiJStUZduv"
+		  },
+		  "block_proof": [
+			{
+			  "direction": "Left",
+			  "hash": "CRVMDaFCLz5GDKtgRzEqi2Rde52yzEDbLabtC2jK7nZm"
+			},
+			{
+			  "direction": "Right",
+			  "hash": "E4HJmteNwLvVzLAyo1C88xj4vb3TkkBFCHfEXgCkNXhN"
+			}
+		  ],
+		  "outcome_proof": {
+			"block_hash": "Ftz6ZvUfZgJYBLWFshD6AknHWSzYgepBt5sM8x8dtSrS",
+			"id": "AYDomG3TpmssBCp2F15qcos9CtharnDHHefKdxToH8Uf",
+			"outcome": {
+			  "executor_id": "receiver.testnet",
+			  "gas_burnt": 2428025202756,
+			  "logs": [],
+			  "metadata": {
+				"gas_profile": null,
+				"version": 1
+			  },
+			  "receipt_ids": [
+				"6rFHZV4eMRtmAbfpsNJz1tjbrDZs5hs8VjmGAFfLeCtd"
+			  ],
+			  "status": {
+				"SuccessValue": ""
+			  },
+			  "tokens_burnt": "242802520275600000000"
+			},
+			"proof": [
+			  {
+				"direction": "Left",
+				"hash": "9s8qU4s1aDdBHcby9YZegdh7kGbZb3XCZsGTyAT8RcaA"
+			  }
+			]
+		  },
+		  "outcome_root_proof": [
+			{
+			  "direction": "Right",
+			  "hash": "4A9zZ1umpi36rXiuaKYJZgAjhUH9WoTrnSBXtA3wMdV2"
+			}
+		  ]
+		}
+	  }
+	`
+
+	RECEIPT_OUTCOME_BLOCK_MERKLE_ROOT = "CMVdT4MpWWJgzsmf42ZHxSr39S3zdHkJ3qsx14U2YkL8"
+
+	// Failed outcome of the refund receipt created by RECEIPT_OUTCOME, with
+	// an error newer than nearcore 1.30. Built like RECEIPT_OUTCOME, it proves
+	// against FAILED_RECEIPT_OUTCOME_BLOCK_MERKLE_ROOT.
+	FAILED_RECEIPT_OUTCOME = `
+	{
+		"id": "dontcare",
+		"jsonrpc": "2.0",
+		"result": {
+		  "block_header_lite": {
+			"inner_lite": {
+			  "block_merkle_root": "7vPXGdNa4mSSbSYQUvGq4UjyMvUbmGCW7kgz5VrA2CbC",
+			  "epoch_id": "EeC8QHiPSdr6CSDhJiCQL4wMR8or33qvkvirzh9Moe6x",
+			  "height": 102367482,
+			  "next_bp_hash": "74P742gjuiU6UTxpzgPR1L4c1iqMu6ZtPxFj656XAyCx",
+			  "next_epoch_id": "4Wu9U6C3P9KAAymDYo5W5hv11yi7Xgw6UnyFS6u8V4T9",
+			  "outcome_root": "8FSerfcgcBerQnPvRfomRgWEPDJeGPV8XkeWwC5LGt4t",
+			  "prev_state_root": "3QcxNqhDHW3XkjVe7bUbn6YkFRJxyXtQnsXSixaQkdWb",
+			  "timestamp": 1665413022391817466,
+			  "timestamp_nanosec": "1665413022391817466"
+			},
+			"inner_rest_hash": "5oWPLnzyGSQvkEJwxrz3zqKLVVbDTL7fz5uyEvKMmBrj",
+			"prev_block_hash": "Ftz6ZvUfZgJYBLWFshD6AknHWSzYgepBt5sM8x8dtSrS"
+		  },
+		  "block_proof": [
+			{
+			  "direction": "Left",
+			  "hash": "CRVMDaFCLz5GDKtgRzEqi2Rde52yzEDbLabtC2jK7nZm"
+			},
+			{
+			  "direction": "Right",
+			  "hash": "E4HJmteNwLvVzLAyo1C88xj4vb3TkkBFCHfEXgCkNXhN"
+			}
+		  ],
+		  "outcome_proof": {
+			"block_hash": "6NsxLxtqExbFkTzwpdPZmpjj853A1hxbH2JaQesDGRYs",
+			"id": "6rFHZV4eMRtmAbfpsNJz1tjbrDZs5hs8VjmGAFfLeCtd",
+			"outcome": {
+			  "executor_id": "receiver.testnet",
+			  "gas_burnt": 2428025202756,
+			  "logs": [],
+			  "metadata": {
+				"gas_profile": null,
+				"version": 1
+			  },
+			  "receipt_ids": [],
+			  "status": {
+				"Failure": {
+				  "ActionError": {
+					"index": 0,
+					"kind": {
+					  "DelegateActionExpired": {}
+					}
+				  }
+				}
+			  },
+			  "tokens_burnt": "242802520275600000000"
+			},
+			"proof": [
+			  {
+				"direction": "Left",
+				"hash": "9s8qU4s1aDdBHcby9YZegdh7kGbZb3XCZsGTyAT8RcaA"
+			  }
+			]
+		  },
+		  "outcome_root_proof": [
+			{
+			  "direction": "Right",
+			  "hash": "4A9zZ1umpi36rXiuaKYJZgAjhUH9WoTrnSBXtA3wMdV2"
+			}
+		  ]
+		}
+	  }
+	`
+
+	FAILED_RECEIPT_OUTCOME_BLOCK_MERKLE_ROOT = "8ids2MPezbBG3XFSupRHkhSGCSgF1Gy3zyZBm7hq4g8o"
 )
 
 func TestBlockMerkleRootVerification(t *testing.T) {
-	err := BlockMerkleRootVerification(LIGHT_CLIENT_BLOCK, EXECUTION_OUTCOME)
+	err := BlockMerkleRootVerification(mock.MockHostFunction{}, LIGHT_CLIENT_BLOCK, EXECUTION_OUTCOME)
 	if err != nil {
 		t.Errorf("failed to verify merkle rooot:%s", err)
 	}
@@ -0,0 +1,112 @@
+// Copyright © 2022, Electron Labs
+
+package mock
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+
+	"filippo.io/edwards25519"
+	"github.com/electron-labs/near-light-client-go/nearprimitive"
+)
+
+// BatchHostFunction is MockHostFunction verifying a batch of ed25519
+// signatures with a single multi-scalar multiplication; only if that fails is
+// every signature verified on its own to find the invalid ones.
+//
+// Like every batch verifier it uses the cofactored verification equation, so
+// it may accept maliciously crafted signatures that ed25519.Verify rejects.
+// Honestly generated signatures verify the same way with both.
+type BatchHostFunction struct {
+	MockHostFunction
+}
+
+var _ nearprimitive.BatchVerifier = BatchHostFunction{}
+
+func (b BatchHostFunction) VerifyBatch(msgs [][]byte, sigs []nearprimitive.Signature, keys []nearprimitive.PublicKey) []bool {
+	return verify_ed25519_batch(b, msgs, sigs, keys)
+}
+
+// verify_ed25519_batch falls back to h's Verify when the batch doesn't verify.
+func verify_ed25519_batch(h nearprimitive.HostFunction, msgs [][]byte, sigs []nearprimitive.Signature, keys []nearprimitive.PublicKey) []bool {
+	res := make([]bool, len(sigs))
+	if len(msgs) != len(sigs) || len(keys) != len(sigs) {
+		return res
+	}
+
+	if len(sigs) > 0 && verify_batch(msgs, sigs, keys) {
+		for i := range res {
+			res[i] = true
+		}
+
+		return res
+	}
+
+	for i := range sigs {
+		res[i] = h.Verify(sigs[i], msgs[i], keys[i])
+	}
+
+	return res
+}
+
+// verify_batch checks that
+//
+//	[8](-sum(z_i * s_i) * B + sum(z_i * R_i) + sum(z_i * k_i * A_i)) == 0
+//
+// for random 128 bit z_i, where k_i = SHA-512(R_i || A_i || M_i).
+func verify_batch(msgs [][]byte, sigs []nearprimitive.Signature, keys []nearprimitive.PublicKey) bool {
+	scalars := make([]*edwards25519.Scalar, 0, 2*len(sigs)+1)
+	points := make([]*edwards25519.Point, 0, 2*len(sigs)+1)
+
+	b_coefficient := edwards25519.NewScalar()
+
+	for i := range sigs {
+		a, err := edwards25519.NewIdentityPoint().SetBytes(keys[i][:])
+		if err != nil {
+			return false
+		}
+
+		r, err := edwards25519.NewIdentityPoint().SetBytes(sigs[i][:32])
+		if err != nil {
+			return false
+		}
+
+		s, err := edwards25519.NewScalar().SetCanonicalBytes(sigs[i][32:])
+		if err != nil {
+			return false
+		}
+
+		digest := sha512.New()
+		digest.Write(sigs[i][:32])
+		digest.Write(keys[i][:])
+		digest.Write(msgs[i])
+		k, err := edwards25519.NewScalar().SetUniformBytes(digest.Sum(nil))
+		if err != nil {
+			return false
+		}
+
+		z_bytes := make([]byte, 32)
+		_, err = rand.Read(z_bytes[:16])
+		if err != nil {
+			return false
+		}
+
+		z, err := edwards25519.NewScalar().SetCanonicalBytes(z_bytes)
+		if err != nil {
+			return false
+		}
+
+		b_coefficient.MultiplyAdd(z, s, b_coefficient)
+
+		scalars = append(scalars, z, edwards25519.NewScalar().Multiply(z, k))
+		points = append(points, r, a)
+	}
+
+	scalars = append(scalars, edwards25519.NewScalar().Negate(b_coefficient))
+	points = append(points, edwards25519.NewGeneratorPoint())
+
+	check := edwards25519.NewIdentityPoint().VarTimeMultiScalarMult(scalars, points)
+	check.MultByCofactor(check)
+
+	return check.Equal(edwards25519.NewIdentityPoint()) == 1
+}
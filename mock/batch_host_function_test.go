@@ -0,0 +1,74 @@
+// Copyright © 2022, Electron Labs
+
+package mock
+
+import (
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/electron-labs/near-light-client-go/nearprimitive"
+)
+
+// counting_host is MockHostFunction counting the signatures it verifies.
+type counting_host struct {
+	MockHostFunction
+	verifies int
+}
+
+func (c *counting_host) Verify(sig nearprimitive.Signature, data []byte, public_key nearprimitive.PublicKey) bool {
+	c.verifies++
+	return c.MockHostFunction.Verify(sig, data, public_key)
+}
+
+func TestBatchHostFunction(t *testing.T) {
+	msgs := [][]byte{}
+	sigs := []nearprimitive.Signature{}
+	keys := []nearprimitive.PublicKey{}
+
+	for i := 0; i < 5; i++ {
+		pub_key, priv_key, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			t.Fatalf("Failed to generate key pair: %s", err)
+		}
+
+		msg := []byte{byte(i)}
+
+		s := nearprimitive.Signature{}
+		s.TryFromRaw(ed25519.Sign(priv_key, msg))
+		p := nearprimitive.PublicKey{}
+		p.TryFromRaw(pub_key)
+
+		msgs = append(msgs, msg)
+		sigs = append(sigs, s)
+		keys = append(keys, p)
+	}
+
+	h := &counting_host{}
+	for i, valid := range verify_ed25519_batch(h, msgs, sigs, keys) {
+		if !valid {
+			t.Errorf("Signature %d did not verify", i)
+		}
+	}
+
+	// A valid batch needs no signature verified on its own
+	if h.verifies != 0 {
+		t.Errorf("Verified %d signatures one by one", h.verifies)
+	}
+
+	sigs[2][0] ^= 1
+
+	h = &counting_host{}
+	for i, valid := range verify_ed25519_batch(h, msgs, sigs, keys) {
+		if valid == (i == 2) {
+			t.Errorf("Unexpected result %v for signature %d", valid, i)
+		}
+	}
+
+	if h.verifies != len(sigs) {
+		t.Errorf("Verified %d signatures one by one", h.verifies)
+	}
+
+	if len(verify_ed25519_batch(h, msgs[:4], sigs, keys)) != len(sigs) {
+		t.Errorf("Unexpected result length")
+	}
+}
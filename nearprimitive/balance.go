@@ -0,0 +1,68 @@
+// Copyright © 2022, Electron Labs
+
+package nearprimitive
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	num "github.com/shabbyrobe/go-num"
+)
+
+// Balance is a u128 amount of yoctoNEAR, stored as 16 little endian bytes so
+// that it borsh encodes like nearcore's u128.
+type Balance [16]byte
+
+func BalanceFromU128(u num.U128) Balance {
+	hi, lo := u.Raw()
+
+	b := Balance{}
+	binary.LittleEndian.PutUint64(b[:8], lo)
+	binary.LittleEndian.PutUint64(b[8:], hi)
+
+	return b
+}
+
+func BalanceFromUint64(v uint64) Balance {
+	return BalanceFromU128(num.U128From64(v))
+}
+
+// ParseBalance parses a decimal string, as found in NEAR JSON-RPC responses.
+func ParseBalance(s string) (Balance, error) {
+	u, in_range, err := num.U128FromString(s)
+	if err != nil {
+		return Balance{}, fmt.Errorf("Failed to parse balance: %w", err)
+	}
+
+	if !in_range {
+		return Balance{}, fmt.Errorf("Balance %s does not fit in a u128", s)
+	}
+
+	return BalanceFromU128(u), nil
+}
+
+// U128 returns the balance for arithmetic.
+func (b Balance) U128() num.U128 {
+	return num.U128FromRaw(binary.LittleEndian.Uint64(b[8:]), binary.LittleEndian.Uint64(b[:8]))
+}
+
+func (b Balance) String() string {
+	return b.U128().String()
+}
+
+func (b Balance) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.String())
+}
+
+func (b *Balance) UnmarshalJSON(data []byte) error {
+	var s string
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		return fmt.Errorf("Failed to parse balance: %w", err)
+	}
+
+	*b, err = ParseBalance(s)
+
+	return err
+}
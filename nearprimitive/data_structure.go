@@ -4,21 +4,21 @@ package nearprimitive
 
 import (
 	"crypto/ed25519"
-	"crypto/sha256"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 
 	base58 "github.com/btcsuite/btcutil/base58"
 	borsh "github.com/near/borsh-go"
-	num "github.com/shabbyrobe/go-num"
 )
 
 type CryptoHash [32]byte
 
-func (c *CryptoHash) HashBytes(byteArray []byte) {
-	digest := sha256.Sum256(byteArray[:])
-	copy(c[:], digest[:32])
+// HashBytes sets c to the host's Sha256 of byteArray.
+func (c *CryptoHash) HashBytes(h HostFunction, byteArray []byte) {
+	*c = h.Sha256(byteArray)
 }
 
 func (c *CryptoHash) AsBytes() []byte {
@@ -35,14 +35,14 @@ func (c *CryptoHash) TryFromRaw(byteArray []byte) error {
 	return nil
 }
 
-func (c *CryptoHash) HashBorsh(borshSerializedArray []byte) error {
+func (c *CryptoHash) HashBorsh(h HostFunction, borshSerializedArray []byte) error {
 	data := []byte{}
 	err := borsh.Deserialize(&data, borshSerializedArray)
 	if err != nil {
 		return fmt.Errorf("Failed to deserialize: %s", err)
 	}
 
-	c.HashBytes(data)
+	c.HashBytes(h, data)
 
 	return nil
 }
@@ -79,8 +79,9 @@ func (s *Signature) TryFromRaw(data []byte) error {
 	return nil
 }
 
-func (s *Signature) Verify(data []byte, public_key *PublicKey) bool {
-	return ed25519.Verify(public_key.GetEd25519PubKey(), data, s.AsBytes())
+// Verify checks s with the host's Verify.
+func (s *Signature) Verify(h HostFunction, data []byte, public_key *PublicKey) bool {
+	return h.Verify(*s, data, *public_key)
 }
 
 type BlockHeight uint64
@@ -188,23 +189,120 @@ func (lc *LightClientBlockLiteView) deserialize(data []byte) error {
 	return nil
 }
 
+// ErrUnknownValidatorStakeVersion is returned for validator stakes of a
+// version this package does not know.
+var ErrUnknownValidatorStakeVersion = errors.New("unknown validator stake version")
+
 type ValidatorStakeViewVersion uint8
 
 const (
 	V1 ValidatorStakeViewVersion = iota
 )
 
+func (v ValidatorStakeViewVersion) String() string {
+	switch v {
+	case V1:
+		return "V1"
+	}
+
+	return fmt.Sprintf("ValidatorStakeViewVersion(%d)", uint8(v))
+}
+
+// ParseValidatorStakeViewVersion parses the validator_stake_struct_version of
+// the rpc.
+func ParseValidatorStakeViewVersion(s string) (ValidatorStakeViewVersion, error) {
+	switch s {
+	case "V1":
+		return V1, nil
+	}
+
+	return 0, fmt.Errorf("%w %q", ErrUnknownValidatorStakeVersion, s)
+}
+
+type KeyType uint8
+
+const (
+	ED25519 KeyType = iota
+)
+
+// borsh-go only writes enum variants that are structs
+type ED25519PublicKey struct {
+	Inner PublicKey
+}
+
+// PublicKeyView is the borsh encoding of a nearcore PublicKey, its KeyType
+// followed by the key. Only ED25519 keys are supported.
+type PublicKeyView struct {
+	Enum    borsh.Enum `borsh_enum:"true"`
+	ED25519 ED25519PublicKey
+}
+
+func NewED25519PublicKeyView(public_key PublicKey) PublicKeyView {
+	return PublicKeyView{Enum: borsh.Enum(ED25519), ED25519: ED25519PublicKey{Inner: public_key}}
+}
+
+func (p PublicKeyView) KeyType() KeyType {
+	return KeyType(p.Enum)
+}
+
+func (p PublicKeyView) GetED25519() (PublicKey, error) {
+	if p.KeyType() != ED25519 {
+		return PublicKey{}, fmt.Errorf("Unsupported key type %d", uint8(p.Enum))
+	}
+
+	return p.ED25519.Inner, nil
+}
+
+// UnmarshalJSON parses the "ed25519:<base58>" form of the rpc
+func (p *PublicKeyView) UnmarshalJSON(data []byte) error {
+	var s string
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		return err
+	}
+
+	key_type, encoded, found := strings.Cut(s, ":")
+	if !found || key_type != "ed25519" {
+		return fmt.Errorf("Unsupported public key %s", s)
+	}
+
+	public_key := PublicKey{}
+	err = public_key.TryFromRaw(base58.Decode(encoded))
+	if err != nil {
+		return err
+	}
+
+	*p = NewED25519PublicKeyView(public_key)
+
+	return nil
+}
+
 type ValidatorStakeViewV1 struct {
 	AccountId AccountId
-	// HACK to borsh serialize it properly
-	Dummy     uint8
-	PublicKey PublicKey
-	Stake     num.U128
+	PublicKey PublicKeyView
+	Stake     Balance
 }
 
+// ValidatorStakeView is the versioned validator stake of nearcore, only the
+// variant selected by Enum is set. Versions added by nearcore go after V1.
 type ValidatorStakeView struct {
-	Version ValidatorStakeViewVersion
-	V1      ValidatorStakeViewV1
+	Enum borsh.Enum `borsh_enum:"true"`
+	V1   ValidatorStakeViewV1
+}
+
+func NewValidatorStakeViewV1(account_id AccountId, public_key PublicKey, stake Balance) ValidatorStakeView {
+	return ValidatorStakeView{
+		Enum: borsh.Enum(V1),
+		V1: ValidatorStakeViewV1{
+			AccountId: account_id,
+			PublicKey: NewED25519PublicKeyView(public_key),
+			Stake:     stake,
+		},
+	}
+}
+
+func (vs ValidatorStakeView) Version() ValidatorStakeViewVersion {
+	return ValidatorStakeViewVersion(vs.Enum)
 }
 
 func (vs ValidatorStakeView) serialize() ([]byte, error) {
@@ -225,12 +323,30 @@ func (vs *ValidatorStakeView) deserialize(data []byte) error {
 	return nil
 }
 
-func (v *ValidatorStakeView) GetValidatorStake() (ValidatorStakeViewV1, error) {
-	if v.Version == V1 {
-		return v.V1, nil
+// ValidatorStake is a ValidatorStakeView with the version specific layout
+// removed.
+type ValidatorStake struct {
+	AccountId AccountId
+	PublicKey PublicKey
+	Stake     Balance
+}
+
+func (v *ValidatorStakeView) GetValidatorStake() (ValidatorStake, error) {
+	switch v.Version() {
+	case V1:
+		public_key, err := v.V1.PublicKey.GetED25519()
+		if err != nil {
+			return ValidatorStake{}, fmt.Errorf("Invalid public key of %s: %w", v.V1.AccountId, err)
+		}
+
+		return ValidatorStake{
+			AccountId: v.V1.AccountId,
+			PublicKey: public_key,
+			Stake:     v.V1.Stake,
+		}, nil
 	}
 
-	return v.V1, fmt.Errorf("Invalid version %v", v.Version)
+	return ValidatorStake{}, fmt.Errorf("%w %v", ErrUnknownValidatorStakeVersion, v.Version())
 }
 
 type LightClientBlockView struct {
@@ -293,9 +409,9 @@ type ExecutionOutcomeView struct {
 	Logs        []string
 	ReceiptIds  []CryptoHash
 	GasBurnt    Gas
-	TokensBurnt num.U128
+	TokensBurnt Balance
 	ExecutorId  AccountId
-	Status      []uint8
+	Status      ExecutionStatusView
 }
 
 func (eo ExecutionOutcomeView) serialize() ([]byte, error) {
@@ -348,10 +464,33 @@ const (
 	Skip
 )
 
+// borsh-go only writes enum variants that are structs
+type ApprovalEndorsement struct {
+	Inner CryptoHash
+}
+
+type ApprovalSkip struct {
+	Inner BlockHeight
+}
+
+// ApprovalInner is what a block producer approves: the previous block's hash
+// if the target height directly follows it, its height otherwise.
 type ApprovalInner struct {
-	InnerType   ApprovalInnerType
-	Endorsement CryptoHash
-	Skip        BlockHeight
+	Enum        borsh.Enum `borsh_enum:"true"`
+	Endorsement ApprovalEndorsement
+	Skip        ApprovalSkip
+}
+
+func NewEndorsement(prev_block_hash CryptoHash) ApprovalInner {
+	return ApprovalInner{Enum: borsh.Enum(Endorsement), Endorsement: ApprovalEndorsement{Inner: prev_block_hash}}
+}
+
+func NewSkip(prev_block_height BlockHeight) ApprovalInner {
+	return ApprovalInner{Enum: borsh.Enum(Skip), Skip: ApprovalSkip{Inner: prev_block_height}}
+}
+
+func (ai ApprovalInner) Type() ApprovalInnerType {
+	return ApprovalInnerType(ai.Enum)
 }
 
 func (ai ApprovalInner) serialize() ([]byte, error) {
@@ -372,11 +511,53 @@ func (ai *ApprovalInner) deserialize(data []byte) error {
 	return nil
 }
 
+// ApprovalMessage is the data block producers sign to approve inner at
+// target_height: borsh(inner) followed by target_height as a little endian
+// u64.
+func ApprovalMessage(inner ApprovalInner, target_height BlockHeight) ([]byte, error) {
+	data, err := inner.serialize()
+	if err != nil {
+		return data, fmt.Errorf("Failed to serialize approval inner: %w", err)
+	}
+
+	target_height_bytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(target_height_bytes, uint64(target_height))
+
+	return append(data, target_height_bytes...), nil
+}
+
 type HostFunction interface {
 	Sha256(data []byte) [32]byte
 	Verify(sig Signature, data []byte, public_key PublicKey) bool
 }
 
+// BatchVerifier is implemented by hosts that verify many signatures faster
+// than one at a time. VerifyBatch returns whether sigs[i] is a valid
+// signature of msgs[i] by keys[i].
+type BatchVerifier interface {
+	HostFunction
+	VerifyBatch(msgs [][]byte, sigs []Signature, keys []PublicKey) []bool
+}
+
+// VerifyBatch uses h's VerifyBatch if h is a BatchVerifier, and verifies
+// every signature on its own otherwise.
+func VerifyBatch(h HostFunction, msgs [][]byte, sigs []Signature, keys []PublicKey) []bool {
+	if bv, ok := h.(BatchVerifier); ok {
+		return bv.VerifyBatch(msgs, sigs, keys)
+	}
+
+	res := make([]bool, len(sigs))
+	if len(msgs) != len(sigs) || len(keys) != len(sigs) {
+		return res
+	}
+
+	for i := range sigs {
+		res[i] = h.Verify(sigs[i], msgs[i], keys[i])
+	}
+
+	return res
+}
+
 func (lb *LightClientBlockView) CurrentBlockHash(h HostFunction) (CryptoHash, error) {
 	inner_lite_ser, err := lb.InnerLite.ToBlockHeaderInnerLiteViewFinal().serialize()
 	if err != nil {
@@ -384,88 +565,10 @@ func (lb *LightClientBlockView) CurrentBlockHash(h HostFunction) (CryptoHash, er
 	}
 
 	inner_lite_hash := h.Sha256(inner_lite_ser)
-	c := &CryptoHash{}
 
 	appended_hashes := append(inner_lite_hash[:], lb.InnerRestHash.AsBytes()...)
 	new_inner_hash := h.Sha256(appended_hashes[:])
 	appended_hashes = append(new_inner_hash[:], lb.PrevBlockHash.AsBytes()...)
 
-	c.HashBytes(appended_hashes)
-
-	return *c, nil
-}
-
-type Unknown struct{}
-
-type SuccessValue struct {
-	Inner string
-}
-
-type SuccessReceiptID struct {
-	Inner CryptoHash
-}
-
-type ExecutionStatusView struct {
-	Enum borsh.Enum `borsh_enum:"true"`
-	// The execution is pending or unknown.
-	Unknown Unknown
-	// The execution has failed.
-	Failure []byte
-	// The final action succeeded and returned some value or an empty vec encoded in base64.
-	SuccessValue SuccessValue
-	// The final action of the receipt returned a promise or the signed transaction was converted
-	// to a receipt. Contains the receipt_id of the generated receipt.
-	SuccessReceiptID SuccessReceiptID
-}
-
-func IntoExecutionStatusView(raw_status map[string]json.RawMessage) (ExecutionStatusView, error) {
-	var status ExecutionStatusView
-	const unknown = 0
-	const executionStatusView = 2
-	const successReceiptID = 3
-	for k, v := range raw_status {
-		switch k {
-		case "Uknonwn":
-			var s string
-			err := json.Unmarshal([]byte(v), &s)
-			if err != nil {
-				return status, err
-			}
-			status = ExecutionStatusView{
-				Enum:    unknown,
-				Unknown: Unknown{},
-			}
-		case "Failure":
-			fmt.Println("Unsupported failure transaction")
-			return status, errors.New("unsupported failure transaction")
-		case "SuccessValues":
-			var s string
-			err := json.Unmarshal([]byte(v), &s)
-			if err != nil {
-				return status, err
-			}
-			status = ExecutionStatusView{
-				Enum: executionStatusView,
-				SuccessValue: SuccessValue{
-					Inner: s,
-				},
-			}
-		case "SuccessReceiptId":
-			var s string
-			err := json.Unmarshal([]byte(v), &s)
-			if err != nil {
-				return status, err
-			}
-			cryptoHash := CryptoHash{}
-			cryptoHash.TryFromRaw(base58.Decode(s))
-			status = ExecutionStatusView{
-				Enum: successReceiptID,
-				SuccessReceiptID: SuccessReceiptID{
-					Inner: cryptoHash,
-				},
-			}
-		}
-	}
-
-	return status, nil
+	return h.Sha256(appended_hashes), nil
 }
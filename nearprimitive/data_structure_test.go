@@ -5,15 +5,40 @@ package nearprimitive
 import (
 	"bytes"
 	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
 	borsh "github.com/near/borsh-go"
 	"reflect"
 	"testing"
 )
 
+// test_host counts its calls, so that hashing or verifying around the host
+// fails a test.
+type test_host struct {
+	hashes   int
+	verifies int
+}
+
+func (th *test_host) Sha256(data []byte) [32]byte {
+	th.hashes++
+	return sha256.Sum256(data)
+}
+
+func (th *test_host) Verify(sig Signature, data []byte, public_key PublicKey) bool {
+	th.verifies++
+	return ed25519.Verify(public_key.GetEd25519PubKey(), data, sig.AsBytes())
+}
+
 func TestCryptoHashBytes(t *testing.T) {
+	h := &test_host{}
 	c := CryptoHash{}
 	byteArray := []byte("hello world\n")
-	c.HashBytes(byteArray)
+	c.HashBytes(h, byteArray)
+	if h.hashes != 1 {
+		t.Errorf("Hashed without the host")
+	}
+
 	expectedHash := []byte{169, 72, 144, 79, 47, 15, 71, 155, 143, 129,
 		151, 105, 75, 48, 24, 75, 13, 46, 209, 193, 205, 42, 30, 192,
 		251, 133, 210, 153, 161, 146, 164, 71}
@@ -31,10 +56,15 @@ func TestCryptoHashBorshBytes(t *testing.T) {
 		t.Errorf("Error while serializing: %s", err)
 	}
 
-	err = c.HashBorsh(serializedData)
+	h := &test_host{}
+	err = c.HashBorsh(h, serializedData)
 	if err != nil {
 		t.Error(err)
 	}
+	if h.hashes != 1 {
+		t.Errorf("Hashed without the host")
+	}
+
 	expectedHash := []byte{169, 72, 144, 79, 47, 15, 71, 155, 143, 129,
 		151, 105, 75, 48, 24, 75, 13, 46, 209, 193, 205, 42, 30, 192,
 		251, 133, 210, 153, 161, 146, 164, 71}
@@ -65,15 +95,20 @@ func TestSignatureVerification(t *testing.T) {
 		t.Errorf("Failed to generate public key: %s", err)
 	}
 
-	if !s.Verify(msg, p) {
+	h := &test_host{}
+	if !s.Verify(h, msg, p) {
 		t.Errorf("Failed to verify the signature")
 	}
+
+	if h.verifies != 1 {
+		t.Errorf("Verified without the host")
+	}
 }
 
 func TestBlockHeaderInnerLiteViewFinalSerde(t *testing.T) {
 	c := &CryptoHash{}
 	data := []byte("hello world\n")
-	c.HashBytes(data)
+	c.HashBytes(&test_host{}, data)
 
 	bf := &BlockHeaderInnerLiteViewFinal{
 		Height:          31,
@@ -102,3 +137,145 @@ func TestBlockHeaderInnerLiteViewFinalSerde(t *testing.T) {
 		t.Errorf("bf: %v\nder_bf: %v", bf, der_bf)
 	}
 }
+
+func TestApprovalMessage(t *testing.T) {
+	c := CryptoHash{}
+	c.HashBytes(&test_host{}, []byte("hello world\n"))
+
+	endorsement, err := ApprovalMessage(NewEndorsement(c), 10)
+	if err != nil {
+		t.Errorf("Failed to build approval message: %s", err)
+	}
+
+	expected := append([]byte{0}, c.AsBytes()...)
+	expected = append(expected, 10, 0, 0, 0, 0, 0, 0, 0)
+	if !bytes.Equal(endorsement, expected) {
+		t.Errorf("Did not match %x", endorsement)
+	}
+
+	skip, err := ApprovalMessage(NewSkip(8), 10)
+	if err != nil {
+		t.Errorf("Failed to build approval message: %s", err)
+	}
+
+	expected = []byte{1, 8, 0, 0, 0, 0, 0, 0, 0, 10, 0, 0, 0, 0, 0, 0, 0}
+	if !bytes.Equal(skip, expected) {
+		t.Errorf("Did not match %x", skip)
+	}
+
+	ai := &ApprovalInner{}
+	err = ai.deserialize(skip[:9])
+	if err != nil {
+		t.Errorf("Failed to deserialize: %s", err)
+	}
+
+	if ai.Type() != Skip || ai.Skip.Inner != 8 {
+		t.Errorf("Unexpected approval inner %v", ai)
+	}
+}
+
+func TestBalance(t *testing.T) {
+	// 2^64 + 2
+	b, err := ParseBalance("18446744073709551618")
+	if err != nil {
+		t.Errorf("Failed to parse balance: %s", err)
+	}
+
+	data, err := borsh.Serialize(b)
+	if err != nil {
+		t.Errorf("Error while serializing: %s", err)
+	}
+
+	expected := []byte{2, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0}
+	if !bytes.Equal(data, expected) {
+		t.Errorf("Did not match %x", data)
+	}
+
+	der_b := Balance{}
+	err = borsh.Deserialize(&der_b, data)
+	if err != nil {
+		t.Errorf("Error while deserializing: %s", err)
+	}
+
+	if der_b != b || der_b.String() != "18446744073709551618" {
+		t.Errorf("Did not match %s", der_b)
+	}
+
+	if BalanceFromU128(b.U128()) != b || BalanceFromUint64(7).String() != "7" {
+		t.Errorf("Failed to convert balance")
+	}
+
+	json_data, err := json.Marshal(b)
+	if err != nil || string(json_data) != `"18446744073709551618"` {
+		t.Errorf("Failed to marshal balance: %s %s", json_data, err)
+	}
+
+	json_b := Balance{}
+	err = json.Unmarshal(json_data, &json_b)
+	if err != nil || json_b != b {
+		t.Errorf("Failed to unmarshal balance: %s", err)
+	}
+
+	for _, s := range []string{"", "-1", "1.5", "0x10", "340282366920938463463374607431768211456"} {
+		_, err := ParseBalance(s)
+		if err == nil {
+			t.Errorf("Parsed invalid balance %q", s)
+		}
+	}
+}
+
+func TestValidatorStakeViewSerde(t *testing.T) {
+	public_key := PublicKey{1, 2, 3}
+	vs := NewValidatorStakeViewV1("alice.near", public_key, BalanceFromUint64(5))
+
+	data, err := vs.serialize()
+	if err != nil {
+		t.Fatalf("Error while serializing: %s", err)
+	}
+
+	// Version, account id, key type, key, stake
+	expected := []byte{0, 10, 0, 0, 0}
+	expected = append(expected, []byte("alice.near")...)
+	expected = append(expected, 0)
+	expected = append(expected, public_key[:]...)
+	expected = append(expected, 5, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0)
+	if !bytes.Equal(data, expected) {
+		t.Errorf("Did not match %x", data)
+	}
+
+	der_vs := ValidatorStakeView{}
+	err = der_vs.deserialize(data)
+	if err != nil {
+		t.Fatalf("Error while deserializing: %s", err)
+	}
+
+	stake, err := der_vs.GetValidatorStake()
+	if err != nil {
+		t.Fatalf("Failed to get validator stake: %s", err)
+	}
+
+	if stake != (ValidatorStake{AccountId: "alice.near", PublicKey: public_key, Stake: BalanceFromUint64(5)}) {
+		t.Errorf("Did not match %v", stake)
+	}
+
+	// A future version and a secp256k1 key
+	for _, i := range []int{0, 15} {
+		unknown := append([]byte{}, data...)
+		unknown[i] = 1
+		err = der_vs.deserialize(unknown)
+		if err == nil {
+			t.Errorf("Deserialized unknown tag at %d", i)
+		}
+	}
+
+	vs.Enum = 1
+	_, err = vs.GetValidatorStake()
+	if !errors.Is(err, ErrUnknownValidatorStakeVersion) {
+		t.Errorf("Expected ErrUnknownValidatorStakeVersion, got %v", err)
+	}
+
+	_, err = ParseValidatorStakeViewVersion("V2")
+	if !errors.Is(err, ErrUnknownValidatorStakeVersion) {
+		t.Errorf("Expected ErrUnknownValidatorStakeVersion, got %v", err)
+	}
+}
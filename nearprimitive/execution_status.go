@@ -0,0 +1,597 @@
+// Copyright © 2022, Electron Labs
+
+package nearprimitive
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	base58 "github.com/btcsuite/btcutil/base58"
+	borsh "github.com/near/borsh-go"
+)
+
+// unmarshal_enum decodes the serde json of a rust enum, "Variant" or
+// {"Variant": payload}, into the borsh-go complex enum v points to. Variant
+// fields are named after the rust variants, or carry the name as json tag;
+// fields tagged json:"-" are not variants.
+// Newtype variants whose payload is not a struct wrap it as Inner.
+func unmarshal_enum(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v).Elem()
+	rt := rv.Type()
+
+	var name string
+	var payload json.RawMessage
+
+	err := json.Unmarshal(data, &name)
+	if err != nil {
+		variants := map[string]json.RawMessage{}
+		err = json.Unmarshal(data, &variants)
+		if err != nil || len(variants) != 1 {
+			return fmt.Errorf("Ill-formed %s: %s", rt.Name(), data)
+		}
+
+		for k, p := range variants {
+			name, payload = k, p
+		}
+	}
+
+	for i := 1; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+
+		variant_name := field.Name
+		if tag := field.Tag.Get("json"); tag == "-" {
+			continue
+		} else if tag != "" {
+			variant_name = tag
+		}
+
+		if variant_name != name {
+			continue
+		}
+
+		rv.Field(0).SetUint(uint64(i - 1))
+
+		if payload == nil {
+			return nil
+		}
+
+		target := rv.Field(i).Addr()
+		if _, ok := target.Interface().(json.Unmarshaler); !ok && field.Type.NumField() == 1 && field.Type.Field(0).Name == "Inner" {
+			target = rv.Field(i).Field(0).Addr()
+		}
+
+		err = json.Unmarshal(payload, target.Interface())
+		if err != nil {
+			return fmt.Errorf("Failed to parse %s %s: %w", rt.Name(), name, err)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("Unknown %s %s", rt.Name(), name)
+}
+
+type ExecutionStatusType uint8
+
+const (
+	// The execution is pending or unknown.
+	StatusUnknown ExecutionStatusType = iota
+	// The execution has failed.
+	StatusFailure
+	// The final action succeeded and returned some value or an empty vec.
+	StatusSuccessValue
+	// The final action of the receipt returned a promise or the signed
+	// transaction was converted to a receipt. Contains the receipt_id of the
+	// generated receipt.
+	StatusSuccessReceiptId
+)
+
+type Unknown struct{}
+
+// Base64 in json
+type SuccessValue struct {
+	Inner []byte
+}
+
+type SuccessReceiptId struct {
+	Inner CryptoHash
+}
+
+func (s *SuccessReceiptId) UnmarshalJSON(data []byte) error {
+	var encoded string
+	err := json.Unmarshal(data, &encoded)
+	if err != nil {
+		return err
+	}
+
+	return s.Inner.TryFromRaw(base58.Decode(encoded))
+}
+
+type ExecutionStatusView struct {
+	Enum             borsh.Enum `borsh_enum:"true"`
+	Unknown          Unknown
+	Failure          TxExecutionError
+	SuccessValue     SuccessValue
+	SuccessReceiptId SuccessReceiptId
+	// The json of a failure that doesn't decode as TxExecutionError, e.g. an
+	// error added to nearcore after this package. Failure is then left empty.
+	UnparsedFailure json.RawMessage `json:"-"`
+}
+
+func (s ExecutionStatusView) Type() ExecutionStatusType {
+	return ExecutionStatusType(s.Enum)
+}
+
+func (s *ExecutionStatusView) UnmarshalJSON(data []byte) error {
+	err := unmarshal_enum(data, s)
+	if err == nil {
+		return nil
+	}
+
+	// Outcome hashes don't commit to the error, an unknown one mustn't make
+	// the outcome unprovable
+	variants := map[string]json.RawMessage{}
+	if json.Unmarshal(data, &variants) != nil || len(variants) != 1 || variants["Failure"] == nil {
+		return err
+	}
+
+	*s = ExecutionStatusView{Enum: borsh.Enum(StatusFailure), UnparsedFailure: variants["Failure"]}
+
+	return nil
+}
+
+// SerializePartial borsh encodes s as nearcore's PartialExecutionStatus, the
+// form execution outcome hashes commit to: a failure is reduced to its tag.
+func (s ExecutionStatusView) SerializePartial() ([]byte, error) {
+	if s.Type() == StatusFailure {
+		return []byte{byte(s.Enum)}, nil
+	}
+
+	data, err := borsh.Serialize(s)
+	if err != nil {
+		return data, fmt.Errorf("Failed to serialize: %w", err)
+	}
+
+	return data, nil
+}
+
+func (s ExecutionStatusView) String() string {
+	switch s.Type() {
+	case StatusUnknown:
+		return "Unknown"
+	case StatusFailure:
+		return "Failure"
+	case StatusSuccessValue:
+		return fmt.Sprintf("SuccessValue(%x)", s.SuccessValue.Inner)
+	case StatusSuccessReceiptId:
+		return fmt.Sprintf("SuccessReceiptId(%s)", base58.Encode(s.SuccessReceiptId.Inner[:]))
+	}
+
+	return fmt.Sprintf("ExecutionStatusView(%d)", uint8(s.Enum))
+}
+
+// The error types below mirror near-primitives' errors and near-vm-errors as
+// of nearcore 1.30, variants must stay in nearcore's order for the borsh
+// encoding to match.
+
+// TxExecutionError is why a transaction or receipt failed.
+type TxExecutionError struct {
+	Enum           borsh.Enum `borsh_enum:"true"`
+	ActionError    ActionError
+	InvalidTxError InvalidTxError
+}
+
+func (e *TxExecutionError) UnmarshalJSON(data []byte) error {
+	return unmarshal_enum(data, e)
+}
+
+// Option<u64>, null in json
+type OptionalIndex struct {
+	Enum borsh.Enum `borsh_enum:"true"`
+	None struct{}
+	Some struct {
+		Inner uint64
+	}
+}
+
+func (o *OptionalIndex) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*o = OptionalIndex{}
+		return nil
+	}
+
+	o.Enum = 1
+	return json.Unmarshal(data, &o.Some.Inner)
+}
+
+// ActionError is the failure of the action at Index, or of the receipt as a
+// whole if Index is None.
+type ActionError struct {
+	Index OptionalIndex   `json:"index"`
+	Kind  ActionErrorKind `json:"kind"`
+}
+
+// Payloads shared by several variants
+
+type AccountIdError struct {
+	AccountId AccountId `json:"account_id"`
+}
+
+type AccountKeyError struct {
+	AccountId AccountId     `json:"account_id"`
+	PublicKey PublicKeyView `json:"public_key"`
+}
+
+type LengthLimitError struct {
+	Length uint64 `json:"length"`
+	Limit  uint64 `json:"limit"`
+}
+
+type SizeLimitError struct {
+	Size  uint64 `json:"size"`
+	Limit uint64 `json:"limit"`
+}
+
+type MessageError struct {
+	Msg string `json:"msg"`
+}
+
+type MethodNameError struct {
+	MethodName string `json:"method_name"`
+}
+
+type InvalidAccountIdError struct {
+	AccountId string `json:"account_id"`
+}
+
+type CreateAccountOnlyByRegistrar struct {
+	AccountId          AccountId `json:"account_id"`
+	RegistrarAccountId AccountId `json:"registrar_account_id"`
+	PredecessorId      AccountId `json:"predecessor_id"`
+}
+
+type CreateAccountNotAllowed struct {
+	AccountId     AccountId `json:"account_id"`
+	PredecessorId AccountId `json:"predecessor_id"`
+}
+
+type ActorNoPermission struct {
+	AccountId AccountId `json:"account_id"`
+	ActorId   AccountId `json:"actor_id"`
+}
+
+type LackBalanceForState struct {
+	AccountId AccountId `json:"account_id"`
+	Amount    Balance   `json:"amount"`
+}
+
+type TriesToStake struct {
+	AccountId AccountId `json:"account_id"`
+	Stake     Balance   `json:"stake"`
+	Locked    Balance   `json:"locked"`
+	Balance   Balance   `json:"balance"`
+}
+
+type InsufficientStake struct {
+	AccountId    AccountId `json:"account_id"`
+	Stake        Balance   `json:"stake"`
+	MinimumStake Balance   `json:"minimum_stake"`
+}
+
+type ActionErrorKind struct {
+	Enum                               borsh.Enum `borsh_enum:"true"`
+	AccountAlreadyExists               AccountIdError
+	AccountDoesNotExist                AccountIdError
+	CreateAccountOnlyByRegistrar       CreateAccountOnlyByRegistrar
+	CreateAccountNotAllowed            CreateAccountNotAllowed
+	ActorNoPermission                  ActorNoPermission
+	DeleteKeyDoesNotExist              AccountKeyError
+	AddKeyAlreadyExists                AccountKeyError
+	DeleteAccountStaking               AccountIdError
+	LackBalanceForState                LackBalanceForState
+	TriesToUnstake                     AccountIdError
+	TriesToStake                       TriesToStake
+	InsufficientStake                  InsufficientStake
+	FunctionCallError                  FunctionCallError
+	NewReceiptValidationError          ReceiptValidationError
+	OnlyImplicitAccountCreationAllowed AccountIdError
+	DeleteAccountWithLargeState        AccountIdError
+}
+
+func (e *ActionErrorKind) UnmarshalJSON(data []byte) error {
+	return unmarshal_enum(data, e)
+}
+
+type ReceiptValidationError struct {
+	Enum                                borsh.Enum `borsh_enum:"true"`
+	InvalidPredecessorId                InvalidAccountIdError
+	InvalidReceiverId                   InvalidAccountIdError
+	InvalidSignerId                     InvalidAccountIdError
+	InvalidDataReceiverId               InvalidAccountIdError
+	ReturnedValueLengthExceeded         LengthLimitError
+	NumberInputDataDependenciesExceeded NumberInputDataDependenciesExceeded
+	ActionsValidation                   ActionsValidationError
+}
+
+func (e *ReceiptValidationError) UnmarshalJSON(data []byte) error {
+	return unmarshal_enum(data, e)
+}
+
+type NumberInputDataDependenciesExceeded struct {
+	NumberOfInputDataDependencies uint64 `json:"number_of_input_data_dependencies"`
+	Limit                         uint64 `json:"limit"`
+}
+
+type TotalPrepaidGasExceeded struct {
+	TotalPrepaidGas Gas `json:"total_prepaid_gas"`
+	Limit           Gas `json:"limit"`
+}
+
+type TotalNumberOfActionsExceeded struct {
+	TotalNumberOfActions uint64 `json:"total_number_of_actions"`
+	Limit                uint64 `json:"limit"`
+}
+
+type AddKeyMethodNamesNumberOfBytesExceeded struct {
+	TotalNumberOfBytes uint64 `json:"total_number_of_bytes"`
+	Limit              uint64 `json:"limit"`
+}
+
+type UnsuitableStakingKey struct {
+	PublicKey PublicKeyView `json:"public_key"`
+}
+
+type ActionsValidationError struct {
+	Enum                                   borsh.Enum `borsh_enum:"true"`
+	DeleteActionMustBeFinal                struct{}
+	TotalPrepaidGasExceeded                TotalPrepaidGasExceeded
+	TotalNumberOfActionsExceeded           TotalNumberOfActionsExceeded
+	AddKeyMethodNamesNumberOfBytesExceeded AddKeyMethodNamesNumberOfBytesExceeded
+	AddKeyMethodNameLengthExceeded         LengthLimitError
+	IntegerOverflow                        struct{}
+	InvalidAccountId                       InvalidAccountIdError
+	ContractSizeExceeded                   SizeLimitError
+	FunctionCallMethodNameLengthExceeded   LengthLimitError
+	FunctionCallArgumentsLengthExceeded    LengthLimitError
+	UnsuitableStakingKey                   UnsuitableStakingKey
+	FunctionCallZeroAttachedGas            struct{}
+}
+
+func (e *ActionsValidationError) UnmarshalJSON(data []byte) error {
+	return unmarshal_enum(data, e)
+}
+
+type InvalidSignerId struct {
+	SignerId string `json:"signer_id"`
+}
+
+type SignerDoesNotExist struct {
+	SignerId AccountId `json:"signer_id"`
+}
+
+type InvalidNonce struct {
+	TxNonce uint64 `json:"tx_nonce"`
+	AkNonce uint64 `json:"ak_nonce"`
+}
+
+type NonceTooLarge struct {
+	TxNonce    uint64 `json:"tx_nonce"`
+	UpperBound uint64 `json:"upper_bound"`
+}
+
+type InvalidReceiverId struct {
+	ReceiverId string `json:"receiver_id"`
+}
+
+type NotEnoughBalance struct {
+	SignerId AccountId `json:"signer_id"`
+	Balance  Balance   `json:"balance"`
+	Cost     Balance   `json:"cost"`
+}
+
+type SignerLackBalanceForState struct {
+	SignerId AccountId `json:"signer_id"`
+	Amount   Balance   `json:"amount"`
+}
+
+type InvalidTxError struct {
+	Enum                    borsh.Enum `borsh_enum:"true"`
+	InvalidAccessKeyError   InvalidAccessKeyError
+	InvalidSignerId         InvalidSignerId
+	SignerDoesNotExist      SignerDoesNotExist
+	InvalidNonce            InvalidNonce
+	NonceTooLarge           NonceTooLarge
+	InvalidReceiverId       InvalidReceiverId
+	InvalidSignature        struct{}
+	NotEnoughBalance        NotEnoughBalance
+	LackBalanceForState     SignerLackBalanceForState
+	CostOverflow            struct{}
+	InvalidChain            struct{}
+	Expired                 struct{}
+	ActionsValidation       ActionsValidationError
+	TransactionSizeExceeded SizeLimitError
+}
+
+func (e *InvalidTxError) UnmarshalJSON(data []byte) error {
+	return unmarshal_enum(data, e)
+}
+
+type ReceiverMismatch struct {
+	TxReceiver AccountId `json:"tx_receiver"`
+	AkReceiver string    `json:"ak_receiver"`
+}
+
+type NotEnoughAllowance struct {
+	AccountId AccountId     `json:"account_id"`
+	PublicKey PublicKeyView `json:"public_key"`
+	Allowance Balance       `json:"allowance"`
+	Cost      Balance       `json:"cost"`
+}
+
+type InvalidAccessKeyError struct {
+	Enum                    borsh.Enum `borsh_enum:"true"`
+	AccessKeyNotFound       AccountKeyError
+	ReceiverMismatch        ReceiverMismatch
+	MethodNameMismatch      MethodNameError
+	RequiresFullAccess      struct{}
+	NotEnoughAllowance      NotEnoughAllowance
+	DepositWithFunctionCall struct{}
+}
+
+func (e *InvalidAccessKeyError) UnmarshalJSON(data []byte) error {
+	return unmarshal_enum(data, e)
+}
+
+// ExecutionError carries the message of a smart contract failure
+type ExecutionError struct {
+	Inner string
+}
+
+// FunctionCallError is near-vm-errors' FunctionCallErrorSer.
+type FunctionCallError struct {
+	Enum               borsh.Enum `borsh_enum:"true"`
+	CompilationError   CompilationError
+	LinkError          MessageError
+	MethodResolveError MethodResolveError
+	WasmTrap           WasmTrap
+	WasmUnknownError   struct{}
+	HostError          HostError
+	EVMError           struct{} `json:"_EVMError"`
+	ExecutionError     ExecutionError
+}
+
+func (e *FunctionCallError) UnmarshalJSON(data []byte) error {
+	return unmarshal_enum(data, e)
+}
+
+type CompilationError struct {
+	Enum                borsh.Enum `borsh_enum:"true"`
+	CodeDoesNotExist    AccountIdError
+	PrepareError        PrepareError
+	WasmerCompileError  MessageError
+	UnsupportedCompiler MessageError
+}
+
+func (e *CompilationError) UnmarshalJSON(data []byte) error {
+	return unmarshal_enum(data, e)
+}
+
+type PrepareError struct {
+	Enum                       borsh.Enum `borsh_enum:"true"`
+	Serialization              struct{}
+	Deserialization            struct{}
+	InternalMemoryDeclared     struct{}
+	GasInstrumentation         struct{}
+	StackHeightInstrumentation struct{}
+	Instantiate                struct{}
+	Memory                     struct{}
+	TooManyFunctions           struct{}
+	TooManyLocals              struct{}
+}
+
+func (e *PrepareError) UnmarshalJSON(data []byte) error {
+	return unmarshal_enum(data, e)
+}
+
+type MethodResolveError struct {
+	Enum                   borsh.Enum `borsh_enum:"true"`
+	MethodEmptyName        struct{}
+	MethodNotFound         struct{}
+	MethodInvalidSignature struct{}
+}
+
+func (e *MethodResolveError) UnmarshalJSON(data []byte) error {
+	return unmarshal_enum(data, e)
+}
+
+type WasmTrap struct {
+	Enum                           borsh.Enum `borsh_enum:"true"`
+	Unreachable                    struct{}
+	IncorrectCallIndirectSignature struct{}
+	MemoryOutOfBounds              struct{}
+	CallIndirectOOB                struct{}
+	IllegalArithmetic              struct{}
+	MisalignedAtomicAccess         struct{}
+	IndirectCallToNull             struct{}
+	StackOverflow                  struct{}
+	GenericTrap                    struct{}
+}
+
+func (e *WasmTrap) UnmarshalJSON(data []byte) error {
+	return unmarshal_enum(data, e)
+}
+
+type GuestPanic struct {
+	PanicMsg string `json:"panic_msg"`
+}
+
+type InvalidPromiseIndex struct {
+	PromiseIdx uint64 `json:"promise_idx"`
+}
+
+type InvalidPromiseResultIndex struct {
+	ResultIdx uint64 `json:"result_idx"`
+}
+
+type InvalidRegisterId struct {
+	RegisterId uint64 `json:"register_id"`
+}
+
+type IteratorIndexError struct {
+	IteratorIndex uint64 `json:"iterator_index"`
+}
+
+type InvalidReceiptIndex struct {
+	ReceiptIndex uint64 `json:"receipt_index"`
+}
+
+type NumberOfLogsExceeded struct {
+	Limit uint64 `json:"limit"`
+}
+
+type NumberPromisesExceeded struct {
+	NumberOfPromises uint64 `json:"number_of_promises"`
+	Limit            uint64 `json:"limit"`
+}
+
+type HostError struct {
+	Enum                                borsh.Enum `borsh_enum:"true"`
+	BadUTF16                            struct{}
+	BadUTF8                             struct{}
+	GasExceeded                         struct{}
+	GasLimitExceeded                    struct{}
+	BalanceExceeded                     struct{}
+	EmptyMethodName                     struct{}
+	GuestPanic                          GuestPanic
+	IntegerOverflow                     struct{}
+	InvalidPromiseIndex                 InvalidPromiseIndex
+	CannotAppendActionToJointPromise    struct{}
+	CannotReturnJointPromise            struct{}
+	InvalidPromiseResultIndex           InvalidPromiseResultIndex
+	InvalidRegisterId                   InvalidRegisterId
+	IteratorWasInvalidated              IteratorIndexError
+	MemoryAccessViolation               struct{}
+	InvalidReceiptIndex                 InvalidReceiptIndex
+	InvalidIteratorIndex                IteratorIndexError
+	InvalidAccountId                    struct{}
+	InvalidMethodName                   struct{}
+	InvalidPublicKey                    struct{}
+	ProhibitedInView                    MethodNameError
+	NumberOfLogsExceeded                NumberOfLogsExceeded
+	KeyLengthExceeded                   LengthLimitError
+	ValueLengthExceeded                 LengthLimitError
+	TotalLogLengthExceeded              LengthLimitError
+	NumberPromisesExceeded              NumberPromisesExceeded
+	NumberInputDataDependenciesExceeded NumberInputDataDependenciesExceeded
+	ReturnedValueLengthExceeded         LengthLimitError
+	ContractSizeExceeded                SizeLimitError
+	Deprecated                          MethodNameError
+	ECRecoverError                      MessageError
+	AltBn128InvalidInput                MessageError
+	Ed25519VerifyInvalidInput           MessageError
+}
+
+func (e *HostError) UnmarshalJSON(data []byte) error {
+	return unmarshal_enum(data, e)
+}
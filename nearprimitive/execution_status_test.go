@@ -0,0 +1,222 @@
+// Copyright © 2022, Electron Labs
+
+package nearprimitive
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	base58 "github.com/btcsuite/btcutil/base58"
+	borsh "github.com/near/borsh-go"
+)
+
+const (
+	STATUS_UNKNOWN               = `"Unknown"`
+	STATUS_SUCCESS_VALUE         = `{"SuccessValue": "eyJvayI6dHJ1ZX0="}`
+	STATUS_SUCCESS_RECEIPT_ID    = `{"SuccessReceiptId": "8hxkU4avDWFDCsZckig7oN2ypnYvLyb1qmZ3SA1t8iZK"}`
+	STATUS_FUNCTION_CALL_FAILURE = `
+{
+	"Failure": {
+		"ActionError": {
+			"index": 0,
+			"kind": {
+				"FunctionCallError": {
+					"ExecutionError": "Smart contract panicked: Not enough balance"
+				}
+			}
+		}
+	}
+}`
+	STATUS_ACCOUNT_FAILURE = `
+{
+	"Failure": {
+		"ActionError": {
+			"index": null,
+			"kind": {
+				"DeleteKeyDoesNotExist": {
+					"account_id": "bob.near",
+					"public_key": "ed25519:9KnjTjL6vVoM8heHvCcTgLZ67FwFkiLsNtknFAVsVvYY"
+				}
+			}
+		}
+	}
+}`
+	STATUS_INVALID_TX_FAILURE = `
+{
+	"Failure": {
+		"InvalidTxError": {
+			"InvalidNonce": {
+				"tx_nonce": 5,
+				"ak_nonce": 6
+			}
+		}
+	}
+}`
+	STATUS_WASM_TRAP_FAILURE = `
+{
+	"Failure": {
+		"ActionError": {
+			"index": 2,
+			"kind": {
+				"FunctionCallError": {
+					"WasmTrap": "Unreachable"
+				}
+			}
+		}
+	}
+}`
+	// Added to nearcore after 1.30
+	STATUS_DELEGATE_ACTION_FAILURE = `
+{
+	"Failure": {
+		"ActionError": {
+			"index": 0,
+			"kind": {
+				"DelegateActionExpired": {}
+			}
+		}
+	}
+}`
+)
+
+func le_u64(n uint64) []byte {
+	data := make([]byte, 8)
+	binary.LittleEndian.PutUint64(data, n)
+	return data
+}
+
+func borsh_string(s string) []byte {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, uint32(len(s)))
+	return append(data, s...)
+}
+
+func TestExecutionStatusView(t *testing.T) {
+	receipt_id := base58.Decode("8hxkU4avDWFDCsZckig7oN2ypnYvLyb1qmZ3SA1t8iZK")
+	public_key := base58.Decode("9KnjTjL6vVoM8heHvCcTgLZ67FwFkiLsNtknFAVsVvYY")
+
+	concat := func(parts ...[]byte) []byte {
+		return bytes.Join(parts, nil)
+	}
+
+	tests := []struct {
+		name     string
+		json     string
+		type_    ExecutionStatusType
+		expected []byte
+		partial  []byte
+	}{
+		{"unknown", STATUS_UNKNOWN, StatusUnknown, []byte{0}, []byte{0}},
+		{"success value", STATUS_SUCCESS_VALUE, StatusSuccessValue, concat([]byte{2}, borsh_string(`{"ok":true}`)), concat([]byte{2}, borsh_string(`{"ok":true}`))},
+		{"success receipt id", STATUS_SUCCESS_RECEIPT_ID, StatusSuccessReceiptId, concat([]byte{3}, receipt_id), concat([]byte{3}, receipt_id)},
+		// Failure, ActionError, Some(0), FunctionCallError, ExecutionError
+		{"function call failure", STATUS_FUNCTION_CALL_FAILURE, StatusFailure, concat([]byte{1, 0, 1}, le_u64(0), []byte{12, 7}, borsh_string("Smart contract panicked: Not enough balance")), []byte{1}},
+		// Failure, ActionError, None, DeleteKeyDoesNotExist, ed25519 key
+		{"account failure", STATUS_ACCOUNT_FAILURE, StatusFailure, concat([]byte{1, 0, 0, 5}, borsh_string("bob.near"), []byte{0}, public_key), []byte{1}},
+		// Failure, InvalidTxError, InvalidNonce
+		{"invalid tx failure", STATUS_INVALID_TX_FAILURE, StatusFailure, concat([]byte{1, 1, 3}, le_u64(5), le_u64(6)), []byte{1}},
+		// Failure, ActionError, Some(2), FunctionCallError, WasmTrap, Unreachable
+		{"wasm trap failure", STATUS_WASM_TRAP_FAILURE, StatusFailure, concat([]byte{1, 0, 1}, le_u64(2), []byte{12, 3, 0}), []byte{1}},
+	}
+
+	for _, test := range tests {
+		status := ExecutionStatusView{}
+		err := json.Unmarshal([]byte(test.json), &status)
+		if err != nil {
+			t.Errorf("%s: Failed to parse status: %s", test.name, err)
+			continue
+		}
+
+		if status.Type() != test.type_ {
+			t.Errorf("%s: Unexpected status %s", test.name, status)
+		}
+
+		data, err := borsh.Serialize(status)
+		if err != nil {
+			t.Errorf("%s: Error while serializing: %s", test.name, err)
+		}
+
+		if !bytes.Equal(data, test.expected) {
+			t.Errorf("%s: Did not match %v", test.name, data)
+		}
+
+		partial, err := status.SerializePartial()
+		if err != nil {
+			t.Errorf("%s: Error while serializing partial status: %s", test.name, err)
+		}
+
+		if !bytes.Equal(partial, test.partial) {
+			t.Errorf("%s: Partial status did not match %v", test.name, partial)
+		}
+
+		der_status := ExecutionStatusView{}
+		err = borsh.Deserialize(&der_status, data)
+		if err != nil {
+			t.Errorf("%s: Error while deserializing: %s", test.name, err)
+		}
+
+		redata, _ := borsh.Serialize(der_status)
+		if !bytes.Equal(redata, data) {
+			t.Errorf("%s: Round trip did not match %v", test.name, redata)
+		}
+	}
+
+	status := ExecutionStatusView{}
+	err := json.Unmarshal([]byte(STATUS_FUNCTION_CALL_FAILURE), &status)
+	if err != nil {
+		t.Fatalf("Failed to parse status: %s", err)
+	}
+
+	kind := status.Failure.ActionError.Kind
+	if kind.FunctionCallError.ExecutionError.Inner != "Smart contract panicked: Not enough balance" {
+		t.Errorf("Unexpected failure %v", kind)
+	}
+
+	for _, invalid := range []string{
+		`"Uknonwn"`,
+		`{"SuccessValues": ""}`,
+		`{"SuccessReceiptId": "1"}`,
+	} {
+		err := json.Unmarshal([]byte(invalid), &ExecutionStatusView{})
+		if err == nil {
+			t.Errorf("Parsed invalid status %s", invalid)
+		}
+	}
+
+	// Failures that don't decode are still failures
+	for _, unparsed := range []string{
+		STATUS_DELEGATE_ACTION_FAILURE,
+		`{"Failure": {"ActionError": {"index": 0, "kind": "Bogus"}}}`,
+		`{"Failure": {"InvalidTxError": "InvalidSignature", "ActionError": {}}}`,
+	} {
+		status := ExecutionStatusView{}
+		err := json.Unmarshal([]byte(unparsed), &status)
+		if err != nil {
+			t.Errorf("Failed to parse status %s: %s", unparsed, err)
+			continue
+		}
+
+		if status.Type() != StatusFailure {
+			t.Errorf("Unexpected status %s", status)
+		}
+
+		variants := map[string]json.RawMessage{}
+		json.Unmarshal([]byte(unparsed), &variants)
+		if !bytes.Equal(status.UnparsedFailure, variants["Failure"]) {
+			t.Errorf("Unexpected unparsed failure %s", status.UnparsedFailure)
+		}
+
+		partial, err := status.SerializePartial()
+		if err != nil || !bytes.Equal(partial, []byte{1}) {
+			t.Errorf("Partial status did not match %v", partial)
+		}
+	}
+
+	status = ExecutionStatusView{}
+	err = json.Unmarshal([]byte(STATUS_INVALID_TX_FAILURE), &status)
+	if err != nil || status.UnparsedFailure != nil {
+		t.Errorf("Known failure left unparsed: %s", status.UnparsedFailure)
+	}
+}
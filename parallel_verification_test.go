@@ -0,0 +1,124 @@
+// Copyright © 2022, Electron Labs
+
+package light
+
+import (
+	"errors"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/electron-labs/near-light-client-go/mock"
+	"github.com/electron-labs/near-light-client-go/nearprimitive"
+)
+
+func TestParallelVerificationIsDeterministic(t *testing.T) {
+	h := mock.MockHostFunction{}
+	validators := new_synthetic_validators(t, "bp", 32)
+	next_validators := new_synthetic_stake_views(t, "next", 4)
+
+	head := new_synthetic_block(t, h, 100, synthetic_epoch_0, synthetic_epoch_1, synthetic_stake_views(validators), 0)
+	producers := map[nearprimitive.CryptoHash][]nearprimitive.ValidatorStakeView{
+		synthetic_epoch_1: synthetic_stake_views(validators),
+	}
+
+	block_view := new_synthetic_block(t, h, 110, synthetic_epoch_1, synthetic_epoch_2, next_validators, 1)
+	sign_synthetic_block(t, h, &block_view, validators, func(i int) bool { return i%7 != 0 })
+
+	expected_report, err := ValidateLightBlockDetailed(h, &head, &block_view, producers)
+	if err != nil {
+		t.Fatalf("Failed to validate block: %s", err)
+	}
+
+	for _, workers := range []int{0, 2, 3, 8, 32, 64} {
+		report, err := ValidateLightBlockDetailed(h, &head, &block_view, producers, WithWorkers(workers))
+		if err != nil {
+			t.Errorf("Failed to validate block with %d workers: %s", workers, err)
+		}
+
+		if !reflect.DeepEqual(report, expected_report) {
+			t.Errorf("Report with %d workers differs from the sequential one", workers)
+		}
+	}
+
+	// Forge approvals in different chunks, the lowest index must win
+	for _, i := range []int{25, 9, 30} {
+		forged_signature := *block_view.ApprovalsAfterNext[i]
+		forged_signature[0] ^= 1
+		block_view.ApprovalsAfterNext[i] = &forged_signature
+	}
+
+	for _, workers := range []int{1, 2, 3, 8, 32} {
+		for run := 0; run < 10; run++ {
+			err := ValidateLightBlock(h, &head, &block_view, producers, WithWorkers(workers))
+
+			var signature_err *InvalidSignatureError
+			if !errors.As(err, &signature_err) || signature_err.Index != 9 {
+				t.Fatalf("Expected an invalid signature at index 9 with %d workers, got %v", workers, err)
+			}
+		}
+	}
+}
+
+func TestLightClientVerifyWorkers(t *testing.T) {
+	pre_epoch, err := GetClientBlockView(CLIENT_RESPONSE_PREVIOUS_EPOCH)
+	if err != nil {
+		t.Errorf("Failed to parse prev client block: %s", err)
+	}
+
+	curr_epoch, err := GetClientBlockView(CLIENT_BLOCK_RESPONSE)
+	if err != nil {
+		t.Errorf("Failed to parse current client block: %s", err)
+	}
+
+	lc := NewLightClient(mock.BatchHostFunction{}, WithVerifyWorkers(4))
+	err = lc.Bootstrap(pre_epoch, 1)
+	if err != nil {
+		t.Fatalf("Failed to bootstrap: %s", err)
+	}
+
+	err = lc.ValidateAndUpdateHead(curr_epoch)
+	if err != nil {
+		t.Errorf("Failed to validate block: %s", err)
+	}
+}
+
+func benchmark_validate_light_block(b *testing.B, h nearprimitive.HostFunction, workers int) {
+	pre_epoch, err := GetClientBlockView(CLIENT_RESPONSE_PREVIOUS_EPOCH)
+	if err != nil {
+		b.Fatalf("Failed to parse prev client block: %s", err)
+	}
+
+	curr_epoch, err := GetClientBlockView(CLIENT_BLOCK_RESPONSE)
+	if err != nil {
+		b.Fatalf("Failed to parse current client block: %s", err)
+	}
+
+	producers := map[nearprimitive.CryptoHash][]nearprimitive.ValidatorStakeView{
+		pre_epoch.InnerLite.NextEpochId: pre_epoch.NextBps,
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := ValidateLightBlock(h, &pre_epoch, &curr_epoch, producers, WithWorkers(workers))
+		if err != nil {
+			b.Fatalf("Failed to validate block: %s", err)
+		}
+	}
+}
+
+func BenchmarkValidateLightBlockSequential(b *testing.B) {
+	benchmark_validate_light_block(b, mock.MockHostFunction{}, 1)
+}
+
+func BenchmarkValidateLightBlockParallel(b *testing.B) {
+	benchmark_validate_light_block(b, mock.MockHostFunction{}, runtime.NumCPU())
+}
+
+func BenchmarkValidateLightBlockBatch(b *testing.B) {
+	benchmark_validate_light_block(b, mock.BatchHostFunction{}, 1)
+}
+
+func BenchmarkValidateLightBlockBatchParallel(b *testing.B) {
+	benchmark_validate_light_block(b, mock.BatchHostFunction{}, runtime.NumCPU())
+}
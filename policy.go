@@ -0,0 +1,192 @@
+// Copyright © 2022, Electron Labs
+
+package light
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/electron-labs/near-light-client-go/nearprimitive"
+)
+
+// ErrPolicyRejected is wrapped by every rejection of the built-in policies.
+var ErrPolicyRejected = errors.New("block rejected by policy")
+
+// Policy adds rules on top of the protocol checks. Check is only called for
+// blocks that passed them, report describes how the block was approved.
+type Policy interface {
+	Check(head *nearprimitive.LightClientBlockView, block_view *nearprimitive.LightClientBlockView, report ValidationReport) error
+}
+
+type PolicyFunc func(head *nearprimitive.LightClientBlockView, block_view *nearprimitive.LightClientBlockView, report ValidationReport) error
+
+func (f PolicyFunc) Check(head *nearprimitive.LightClientBlockView, block_view *nearprimitive.LightClientBlockView, report ValidationReport) error {
+	return f(head, block_view, report)
+}
+
+// WithPolicy rejects blocks that don't pass policy.
+func WithPolicy(policy Policy) ValidateOption {
+	return func(c *validate_config) {
+		c.policy = policy
+	}
+}
+
+func check_policy(config validate_config, head *nearprimitive.LightClientBlockView, block_view *nearprimitive.LightClientBlockView, report ValidationReport) error {
+	if config.policy == nil {
+		return nil
+	}
+
+	return config.policy.Check(head, block_view, report)
+}
+
+// Policies requires every policy to pass, the first rejection is returned.
+func Policies(policies ...Policy) Policy {
+	return PolicyFunc(func(head *nearprimitive.LightClientBlockView, block_view *nearprimitive.LightClientBlockView, report ValidationReport) error {
+		for _, policy := range policies {
+			err := policy.Check(head, block_view, report)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+type HeightJumpError struct {
+	HeadHeight nearprimitive.BlockHeight
+	Height     nearprimitive.BlockHeight
+	MaxJump    uint64
+}
+
+func (e *HeightJumpError) Error() string {
+	return fmt.Sprintf("%s: height jumps from %d to %d, more than %d", ErrPolicyRejected, e.HeadHeight, e.Height, e.MaxJump)
+}
+
+func (e *HeightJumpError) Unwrap() error {
+	return ErrPolicyRejected
+}
+
+// MaxHeightJump rejects blocks more than max_jump heights ahead of the head.
+func MaxHeightJump(max_jump uint64) Policy {
+	return PolicyFunc(func(head *nearprimitive.LightClientBlockView, block_view *nearprimitive.LightClientBlockView, report ValidationReport) error {
+		if uint64(block_view.InnerLite.Height-head.InnerLite.Height) > max_jump {
+			return &HeightJumpError{HeadHeight: head.InnerLite.Height, Height: block_view.InnerLite.Height, MaxJump: max_jump}
+		}
+
+		return nil
+	})
+}
+
+// Clock returns the current time.
+type Clock func() time.Time
+
+type TimestampDriftError struct {
+	Timestamp time.Time
+	Now       time.Time
+	MaxDrift  time.Duration
+}
+
+func (e *TimestampDriftError) Error() string {
+	return fmt.Sprintf("%s: block timestamp %s is more than %s away from %s", ErrPolicyRejected, e.Timestamp.UTC().Format(time.RFC3339Nano), e.MaxDrift, e.Now.UTC().Format(time.RFC3339Nano))
+}
+
+func (e *TimestampDriftError) Unwrap() error {
+	return ErrPolicyRejected
+}
+
+// block_time is the block's timestamp as a time.Time. Timestamp, unlike
+// TimestampNanosec, is covered by the block hash.
+func block_time(block_view *nearprimitive.LightClientBlockView) time.Time {
+	return time.Unix(0, int64(block_view.InnerLite.Timestamp))
+}
+
+// MaxTimestampDrift rejects blocks whose timestamp is more than max_drift
+// before or after clock's time, catching stale feeds as well as blocks from
+// the future.
+//
+// It is meant for live tailing only: catching up, e.g. through a Syncer,
+// applies historical blocks that are all rejected as stale. Blocks from the
+// future are always rejected, see WithMaxFutureDrift.
+func MaxTimestampDrift(max_drift time.Duration, clock Clock) Policy {
+	return PolicyFunc(func(head *nearprimitive.LightClientBlockView, block_view *nearprimitive.LightClientBlockView, report ValidationReport) error {
+		now := clock()
+		timestamp := block_time(block_view)
+
+		drift := now.Sub(timestamp)
+		if drift < 0 {
+			drift = -drift
+		}
+
+		if drift > max_drift {
+			return &TimestampDriftError{Timestamp: timestamp, Now: now, MaxDrift: max_drift}
+		}
+
+		return nil
+	})
+}
+
+type TooFewSignersError struct {
+	Signers    int
+	MinSigners int
+}
+
+func (e *TooFewSignersError) Error() string {
+	return fmt.Sprintf("%s: %d distinct signers, at least %d required", ErrPolicyRejected, e.Signers, e.MinSigners)
+}
+
+func (e *TooFewSignersError) Unwrap() error {
+	return ErrPolicyRejected
+}
+
+// MinSigners rejects blocks with valid approvals from fewer than min_signers
+// distinct accounts.
+func MinSigners(min_signers int) Policy {
+	return PolicyFunc(func(head *nearprimitive.LightClientBlockView, block_view *nearprimitive.LightClientBlockView, report ValidationReport) error {
+		signers := map[nearprimitive.AccountId]bool{}
+		for _, approval := range report.Approvals {
+			if approval.Status == ApprovalSigned {
+				signers[approval.AccountId] = true
+			}
+		}
+
+		if len(signers) < min_signers {
+			return &TooFewSignersError{Signers: len(signers), MinSigners: min_signers}
+		}
+
+		return nil
+	})
+}
+
+type DeniedAccountError struct {
+	// Index of the approval
+	Index     int
+	AccountId nearprimitive.AccountId
+}
+
+func (e *DeniedAccountError) Error() string {
+	return fmt.Sprintf("%s: approval %d from denied account %s", ErrPolicyRejected, e.Index, e.AccountId)
+}
+
+func (e *DeniedAccountError) Unwrap() error {
+	return ErrPolicyRejected
+}
+
+// DenyAccounts rejects blocks approved by any of account_ids.
+func DenyAccounts(account_ids ...nearprimitive.AccountId) Policy {
+	denied := map[nearprimitive.AccountId]bool{}
+	for _, account_id := range account_ids {
+		denied[account_id] = true
+	}
+
+	return PolicyFunc(func(head *nearprimitive.LightClientBlockView, block_view *nearprimitive.LightClientBlockView, report ValidationReport) error {
+		for i, approval := range report.Approvals {
+			if approval.Status == ApprovalSigned && denied[approval.AccountId] {
+				return &DeniedAccountError{Index: i, AccountId: approval.AccountId}
+			}
+		}
+
+		return nil
+	})
+}
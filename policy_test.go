@@ -0,0 +1,122 @@
+// Copyright © 2022, Electron Labs
+
+package light
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/electron-labs/near-light-client-go/mock"
+	"github.com/electron-labs/near-light-client-go/nearprimitive"
+)
+
+func TestPolicies(t *testing.T) {
+	h := mock.MockHostFunction{}
+	validators := new_synthetic_validators(t, "bp", 4)
+	next_validators := new_synthetic_stake_views(t, "next", 4)
+
+	head := new_synthetic_block(t, h, 100, synthetic_epoch_0, synthetic_epoch_1, synthetic_stake_views(validators), 0)
+	producers := map[nearprimitive.CryptoHash][]nearprimitive.ValidatorStakeView{
+		synthetic_epoch_1: synthetic_stake_views(validators),
+	}
+
+	// bp0.near doesn't sign
+	block_view := new_synthetic_block(t, h, 110, synthetic_epoch_1, synthetic_epoch_2, next_validators, 1)
+	sign_synthetic_block(t, h, &block_view, validators, func(i int) bool { return i != 0 })
+
+	block_timestamp := time.Unix(0, int64(block_view.InnerLite.TimestampNanosec))
+	clock_at := func(t time.Time) Clock {
+		return func() time.Time { return t }
+	}
+
+	for _, policy := range []Policy{
+		MaxHeightJump(10),
+		MaxTimestampDrift(time.Minute, clock_at(block_timestamp.Add(time.Minute))),
+		MaxTimestampDrift(time.Minute, clock_at(block_timestamp.Add(-time.Minute))),
+		MinSigners(3),
+		DenyAccounts("bp0.near", "other.near"),
+		Policies(MaxHeightJump(10), MinSigners(3)),
+		Policies(),
+	} {
+		err := ValidateLightBlock(h, &head, &block_view, producers, WithPolicy(policy))
+		if err != nil {
+			t.Errorf("Failed to validate block: %s", err)
+		}
+	}
+
+	err := ValidateLightBlock(h, &head, &block_view, producers, WithPolicy(MaxHeightJump(9)))
+	var jump_err *HeightJumpError
+	if !errors.As(err, &jump_err) || jump_err.HeadHeight != 100 || jump_err.Height != 110 {
+		t.Errorf("Expected a HeightJumpError, got %v", err)
+	}
+
+	// Stale and future blocks
+	for _, now := range []time.Time{block_timestamp.Add(time.Minute + 1), block_timestamp.Add(-time.Minute - 1)} {
+		err = ValidateLightBlock(h, &head, &block_view, producers, WithPolicy(MaxTimestampDrift(time.Minute, clock_at(now))))
+		var drift_err *TimestampDriftError
+		if !errors.As(err, &drift_err) || !drift_err.Timestamp.Equal(block_timestamp) || !drift_err.Now.Equal(now) {
+			t.Errorf("Expected a TimestampDriftError, got %v", err)
+		}
+	}
+
+	err = ValidateLightBlock(h, &head, &block_view, producers, WithPolicy(MinSigners(4)))
+	var signers_err *TooFewSignersError
+	if !errors.As(err, &signers_err) || signers_err.Signers != 3 || signers_err.MinSigners != 4 {
+		t.Errorf("Expected a TooFewSignersError, got %v", err)
+	}
+
+	err = ValidateLightBlock(h, &head, &block_view, producers, WithPolicy(DenyAccounts("bp2.near")))
+	var denied_err *DeniedAccountError
+	if !errors.As(err, &denied_err) || denied_err.Index != 2 || denied_err.AccountId != "bp2.near" {
+		t.Errorf("Expected a DeniedAccountError, got %v", err)
+	}
+
+	// The first rejection wins
+	err = ValidateLightBlock(h, &head, &block_view, producers, WithPolicy(Policies(MinSigners(3), DenyAccounts("bp1.near"), MaxHeightJump(1))))
+	if !errors.As(err, &denied_err) || !errors.Is(err, ErrPolicyRejected) {
+		t.Errorf("Expected a DeniedAccountError, got %v", err)
+	}
+
+	// Policies only see blocks that passed the protocol checks
+	called := false
+	stale := new_synthetic_block(t, h, 90, synthetic_epoch_1, synthetic_epoch_2, next_validators, 1)
+	sign_synthetic_block(t, h, &stale, validators, all_sign)
+
+	err = ValidateLightBlock(h, &head, &stale, producers, WithPolicy(PolicyFunc(func(head *nearprimitive.LightClientBlockView, block_view *nearprimitive.LightClientBlockView, report ValidationReport) error {
+		called = true
+		return nil
+	})))
+	if !errors.Is(err, ErrHeightNotAhead) || called {
+		t.Errorf("Expected ErrHeightNotAhead without consulting the policy, got %v", err)
+	}
+}
+
+func TestLightClientPolicy(t *testing.T) {
+	h := mock.MockHostFunction{}
+	validators := new_synthetic_validators(t, "bp", 4)
+	next_validators := new_synthetic_stake_views(t, "next", 4)
+
+	lc := new_synthetic_client(t, h, validators, WithValidateOptions(WithPolicy(DenyAccounts("bp3.near"))))
+
+	block_view := new_synthetic_block(t, h, 110, synthetic_epoch_1, synthetic_epoch_2, next_validators, 1)
+	sign_synthetic_block(t, h, &block_view, validators, all_sign)
+
+	err := lc.ValidateAndUpdateHead(block_view)
+	var denied_err *DeniedAccountError
+	if !errors.As(err, &denied_err) || denied_err.AccountId != "bp3.near" {
+		t.Errorf("Expected a DeniedAccountError, got %v", err)
+	}
+
+	if lc.CurrentBlockHeight() != 100 {
+		t.Errorf("Head should not have advanced")
+	}
+
+	block_view = new_synthetic_block(t, h, 111, synthetic_epoch_1, synthetic_epoch_2, next_validators, 1)
+	sign_synthetic_block(t, h, &block_view, validators, func(i int) bool { return i != 3 })
+
+	err = lc.ValidateAndUpdateHead(block_view)
+	if err != nil {
+		t.Errorf("Failed to validate block: %s", err)
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright © 2022, Electron Labs
+
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	base58 "github.com/btcsuite/btcutil/base58"
+	light "github.com/electron-labs/near-light-client-go"
+	"github.com/electron-labs/near-light-client-go/nearprimitive"
+)
+
+const (
+	MainnetEndpoint = "https://rpc.mainnet.near.org"
+	TestnetEndpoint = "https://rpc.testnet.near.org"
+)
+
+// Error is a JSON-RPC error object returned by a NEAR node.
+type Error struct {
+	Name    string          `json:"name"`
+	Cause   *ErrorCause     `json:"cause"`
+	Code    int64           `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type ErrorCause struct {
+	Name string          `json:"name"`
+	Info json.RawMessage `json:"info"`
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("rpc error %d %s: %s (%s)", e.Code, e.Name, e.Message, e.Cause.Name)
+	}
+
+	return fmt.Sprintf("rpc error %d %s: %s", e.Code, e.Name, e.Message)
+}
+
+type request struct {
+	Jsonrpc string      `json:"jsonrpc"`
+	Id      string      `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+type response struct {
+	Result json.RawMessage `json:"result"`
+	Error  *Error          `json:"error"`
+}
+
+// LightClientProofRequest holds the params of light_client_proof. Transaction
+// proofs set TransactionHash and SenderId, receipt proofs set ReceiptId and
+// ReceiverId.
+type LightClientProofRequest = light.NearProofRequest
+
+type Option func(*Client)
+
+// WithHTTPClient replaces http.DefaultClient.
+func WithHTTPClient(http_client *http.Client) Option {
+	return func(c *Client) {
+		c.http_client = http_client
+	}
+}
+
+// Client calls the light client methods of a NEAR JSON-RPC endpoint.
+type Client struct {
+	endpoint    string
+	http_client *http.Client
+}
+
+var _ light.BlockSource = (*Client)(nil)
+
+func NewClient(endpoint string, opts ...Option) *Client {
+	c := &Client{
+		endpoint:    endpoint,
+		http_client: http.DefaultClient,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// call sends a JSON-RPC request and returns the raw response body once it is
+// known not to hold an error object.
+func (c *Client) call(ctx context.Context, method string, params interface{}) ([]byte, json.RawMessage, error) {
+	req_body, err := json.Marshal(request{
+		Jsonrpc: "2.0",
+		Id:      "dontcare",
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to serialize request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(req_body))
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http_client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to call %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to read response: %w", err)
+	}
+
+	var r response
+	err = json.Unmarshal(body, &r)
+	if err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, nil, fmt.Errorf("Unexpected status %s from %s", resp.Status, method)
+		}
+
+		return nil, nil, fmt.Errorf("Failed to parse response: %w", err)
+	}
+
+	if r.Error != nil {
+		return nil, nil, r.Error
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("Unexpected status %s from %s", resp.Status, method)
+	}
+
+	return body, r.Result, nil
+}
+
+func is_empty_result(result json.RawMessage) bool {
+	trimmed := bytes.TrimSpace(result)
+	return len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) || bytes.Equal(trimmed, []byte("{}"))
+}
+
+// NextLightClientBlock calls next_light_client_block. It returns nil if the
+// node has no block after last_block_hash yet.
+func (c *Client) NextLightClientBlock(ctx context.Context, last_block_hash nearprimitive.CryptoHash) (*nearprimitive.LightClientBlockView, error) {
+	params := map[string]string{
+		"last_block_hash": base58.Encode(last_block_hash[:]),
+	}
+
+	body, result, err := c.call(ctx, "next_light_client_block", params)
+	if err != nil {
+		return nil, err
+	}
+
+	if is_empty_result(result) {
+		return nil, nil
+	}
+
+	block_view, err := light.GetClientBlockView(string(body))
+	if err != nil {
+		return nil, err
+	}
+
+	return &block_view, nil
+}
+
+// LightClientProof calls light_client_proof with a raw request.
+func (c *Client) LightClientProof(ctx context.Context, params LightClientProofRequest) (light.NearTxResult, error) {
+	body, _, err := c.call(ctx, "light_client_proof", params)
+	if err != nil {
+		return light.NearTxResult{}, err
+	}
+
+	return light.GetNearTxResult(string(body))
+}
+
+// TransactionProof proves the outcome of a transaction against
+// light_client_head.
+func (c *Client) TransactionProof(ctx context.Context, transaction_hash nearprimitive.CryptoHash, sender_id nearprimitive.AccountId, light_client_head nearprimitive.CryptoHash) (light.NearTxResult, error) {
+	return c.LightClientProof(ctx, LightClientProofRequest{
+		Type:            "transaction",
+		TransactionHash: base58.Encode(transaction_hash[:]),
+		SenderId:        string(sender_id),
+		LightClientHead: base58.Encode(light_client_head[:]),
+	})
+}
+
+// ReceiptProof proves the outcome of a receipt against light_client_head.
+func (c *Client) ReceiptProof(ctx context.Context, receipt_id nearprimitive.CryptoHash, receiver_id nearprimitive.AccountId, light_client_head nearprimitive.CryptoHash) (light.NearTxResult, error) {
+	return c.LightClientProof(ctx, LightClientProofRequest{
+		Type:            "receipt",
+		ReceiptId:       base58.Encode(receipt_id[:]),
+		ReceiverId:      string(receiver_id),
+		LightClientHead: base58.Encode(light_client_head[:]),
+	})
+}
+
+// BlockProof calls EXPERIMENTAL_light_client_block_proof to prove that
+// block_hash is included in light_client_head's block merkle root.
+func (c *Client) BlockProof(ctx context.Context, block_hash nearprimitive.CryptoHash, light_client_head nearprimitive.CryptoHash) (light.NearBlockProof, error) {
+	params := map[string]string{
+		"block_hash":        base58.Encode(block_hash[:]),
+		"light_client_head": base58.Encode(light_client_head[:]),
+	}
+
+	body, _, err := c.call(ctx, "EXPERIMENTAL_light_client_block_proof", params)
+	if err != nil {
+		return light.NearBlockProof{}, err
+	}
+
+	return light.GetBlockProof(string(body))
+}
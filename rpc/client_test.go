@@ -0,0 +1,264 @@
+// Copyright © 2022, Electron Labs
+
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/electron-labs/near-light-client-go/nearprimitive"
+)
+
+const (
+	ZERO_HASH = "11111111111111111111111111111111"
+
+	INNER_LITE = `{
+		"block_merkle_root": "11111111111111111111111111111111",
+		"epoch_id": "11111111111111111111111111111111",
+		"height": 42,
+		"next_bp_hash": "11111111111111111111111111111111",
+		"next_epoch_id": "11111111111111111111111111111111",
+		"outcome_root": "11111111111111111111111111111111",
+		"prev_state_root": "11111111111111111111111111111111",
+		"timestamp": 1648794682287664503,
+		"timestamp_nanosec": "1648794682287664503"
+	}`
+
+	NEXT_LIGHT_CLIENT_BLOCK_RESULT = `{
+		"approvals_after_next": [null],
+		"inner_lite": ` + INNER_LITE + `,
+		"inner_rest_hash": "11111111111111111111111111111111",
+		"next_block_inner_hash": "11111111111111111111111111111111",
+		"next_bps": [
+			{
+				"account_id": "node0",
+				"public_key": "ed25519:11111111111111111111111111111111",
+				"stake": "1000",
+				"validator_stake_struct_version": "V1"
+			}
+		],
+		"prev_block_hash": "11111111111111111111111111111111"
+	}`
+
+	LIGHT_CLIENT_PROOF_RESULT = `{
+		"block_header_lite": {
+			"inner_lite": ` + INNER_LITE + `,
+			"inner_rest_hash": "11111111111111111111111111111111",
+			"prev_block_hash": "11111111111111111111111111111111"
+		},
+		"block_proof": [{"direction": "Left", "hash": "11111111111111111111111111111111"}],
+		"outcome_proof": {
+			"block_hash": "11111111111111111111111111111111",
+			"id": "11111111111111111111111111111111",
+			"outcome": {
+				"executor_id": "receiver.near",
+				"gas_burnt": 100,
+				"logs": [],
+				"metadata": {"gas_profile": null, "version": 1},
+				"receipt_ids": [],
+				"status": {"SuccessReceiptId": "11111111111111111111111111111111"},
+				"tokens_burnt": "10"
+			},
+			"proof": []
+		},
+		"outcome_root_proof": []
+	}`
+
+	BLOCK_PROOF_RESULT = `{
+		"block_header_lite": {
+			"inner_lite": ` + INNER_LITE + `,
+			"inner_rest_hash": "11111111111111111111111111111111",
+			"prev_block_hash": "11111111111111111111111111111111"
+		},
+		"block_proof": [{"direction": "Right", "hash": "11111111111111111111111111111111"}]
+	}`
+)
+
+type rpc_request struct {
+	Jsonrpc string            `json:"jsonrpc"`
+	Id      string            `json:"id"`
+	Method  string            `json:"method"`
+	Params  map[string]string `json:"params"`
+}
+
+func new_test_server(t *testing.T, handler func(req rpc_request) string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpc_request
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			t.Errorf("Failed to decode request: %s", err)
+		}
+
+		if req.Jsonrpc != "2.0" {
+			t.Errorf("Unexpected jsonrpc version: %s", req.Jsonrpc)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(handler(req)))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func result_response(result string) string {
+	return `{"jsonrpc": "2.0", "id": "dontcare", "result": ` + result + `}`
+}
+
+func TestNextLightClientBlock(t *testing.T) {
+	server := new_test_server(t, func(req rpc_request) string {
+		if req.Method != "next_light_client_block" {
+			t.Errorf("Unexpected method: %s", req.Method)
+		}
+
+		if req.Params["last_block_hash"] == ZERO_HASH {
+			return result_response(NEXT_LIGHT_CLIENT_BLOCK_RESULT)
+		}
+
+		return result_response(`{}`)
+	})
+
+	c := NewClient(server.URL, WithHTTPClient(server.Client()))
+
+	block_view, err := c.NextLightClientBlock(context.Background(), nearprimitive.CryptoHash{})
+	if err != nil {
+		t.Errorf("Failed to get next light client block: %s", err)
+	}
+
+	if block_view == nil || block_view.InnerLite.Height != 42 || len(block_view.NextBps) != 1 {
+		t.Errorf("Unexpected block: %v", block_view)
+	}
+
+	block_view, err = c.NextLightClientBlock(context.Background(), nearprimitive.CryptoHash{1})
+	if err != nil {
+		t.Errorf("Failed to get next light client block: %s", err)
+	}
+
+	if block_view != nil {
+		t.Errorf("Expected no block when caught up, got %v", block_view)
+	}
+}
+
+func TestLightClientProof(t *testing.T) {
+	server := new_test_server(t, func(req rpc_request) string {
+		if req.Method != "light_client_proof" {
+			t.Errorf("Unexpected method: %s", req.Method)
+		}
+
+		switch req.Params["type"] {
+		case "transaction":
+			if req.Params["transaction_hash"] != ZERO_HASH || req.Params["sender_id"] != "sender.near" {
+				t.Errorf("Unexpected transaction params: %v", req.Params)
+			}
+		case "receipt":
+			if req.Params["receipt_id"] != ZERO_HASH || req.Params["receiver_id"] != "receiver.near" {
+				t.Errorf("Unexpected receipt params: %v", req.Params)
+			}
+		default:
+			t.Errorf("Unexpected proof type: %s", req.Params["type"])
+		}
+
+		if req.Params["light_client_head"] != ZERO_HASH {
+			t.Errorf("Unexpected light client head: %s", req.Params["light_client_head"])
+		}
+
+		return result_response(LIGHT_CLIENT_PROOF_RESULT)
+	})
+
+	c := NewClient(server.URL, WithHTTPClient(server.Client()))
+
+	proof, err := c.TransactionProof(context.Background(), nearprimitive.CryptoHash{}, "sender.near", nearprimitive.CryptoHash{})
+	if err != nil {
+		t.Errorf("Failed to get transaction proof: %s", err)
+	}
+
+	if proof.OutcomeProof.Outcome.ExecutorId != "receiver.near" || len(proof.BlockProof) != 1 {
+		t.Errorf("Unexpected proof: %v", proof)
+	}
+
+	proof, err = c.ReceiptProof(context.Background(), nearprimitive.CryptoHash{}, "receiver.near", nearprimitive.CryptoHash{})
+	if err != nil {
+		t.Errorf("Failed to get receipt proof: %s", err)
+	}
+
+	if proof.BlockHeaderLite.InnerLite.Height != 42 {
+		t.Errorf("Unexpected proof: %v", proof)
+	}
+}
+
+func TestBlockProof(t *testing.T) {
+	server := new_test_server(t, func(req rpc_request) string {
+		if req.Method != "EXPERIMENTAL_light_client_block_proof" {
+			t.Errorf("Unexpected method: %s", req.Method)
+		}
+
+		if req.Params["block_hash"] != ZERO_HASH || req.Params["light_client_head"] != ZERO_HASH {
+			t.Errorf("Unexpected params: %v", req.Params)
+		}
+
+		return result_response(BLOCK_PROOF_RESULT)
+	})
+
+	c := NewClient(server.URL, WithHTTPClient(server.Client()))
+
+	proof, err := c.BlockProof(context.Background(), nearprimitive.CryptoHash{}, nearprimitive.CryptoHash{})
+	if err != nil {
+		t.Errorf("Failed to get block proof: %s", err)
+	}
+
+	if len(proof.BlockProof) != 1 || proof.BlockProof[0].Direction != nearprimitive.Right {
+		t.Errorf("Unexpected block proof: %v", proof)
+	}
+}
+
+func TestRpcError(t *testing.T) {
+	server := new_test_server(t, func(req rpc_request) string {
+		return `{
+			"jsonrpc": "2.0",
+			"id": "dontcare",
+			"error": {
+				"name": "HANDLER_ERROR",
+				"cause": {"name": "UNKNOWN_BLOCK", "info": {}},
+				"code": -32000,
+				"message": "Server error",
+				"data": "DB Not Found Error: BLOCK HEIGHT"
+			}
+		}`
+	})
+
+	c := NewClient(server.URL, WithHTTPClient(server.Client()))
+
+	_, err := c.NextLightClientBlock(context.Background(), nearprimitive.CryptoHash{})
+
+	var rpc_err *Error
+	if !errors.As(err, &rpc_err) {
+		t.Fatalf("Expected an rpc error, got %v", err)
+	}
+
+	if rpc_err.Code != -32000 || rpc_err.Name != "HANDLER_ERROR" || rpc_err.Cause == nil || rpc_err.Cause.Name != "UNKNOWN_BLOCK" {
+		t.Errorf("Unexpected rpc error: %v", rpc_err)
+	}
+
+	if !strings.Contains(err.Error(), "UNKNOWN_BLOCK") {
+		t.Errorf("Error message misses the cause: %s", err)
+	}
+}
+
+func TestHttpError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, WithHTTPClient(server.Client()))
+
+	_, err := c.NextLightClientBlock(context.Background(), nearprimitive.CryptoHash{})
+	if err == nil {
+		t.Errorf("Expected an error for a failed http call")
+	}
+}
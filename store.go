@@ -0,0 +1,406 @@
+// Copyright © 2022, Electron Labs
+
+package light
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/electron-labs/near-light-client-go/nearprimitive"
+	borsh "github.com/near/borsh-go"
+)
+
+// EpochState is a tracked epoch together with the next_bp_hash its block
+// producers were checked against.
+type EpochState struct {
+	EpochId        nearprimitive.CryptoHash
+	NextBpHash     nearprimitive.CryptoHash
+	BlockProducers []nearprimitive.ValidatorStakeView
+}
+
+// LightClientState is everything a LightClient needs to resume.
+type LightClientState struct {
+	Head           nearprimitive.LightClientBlockView
+	HeightsToTrack uint64
+	// Oldest first
+	HeadHistory []nearprimitive.LightClientBlockView
+	// Oldest first
+	Epochs []EpochState
+	// Set once the client halted on conflicting blocks
+	Evidence *EquivocationEvidence
+}
+
+// Store persists LightClientState across restarts.
+type Store interface {
+	// Load returns nil if no state was saved yet.
+	Load() (*LightClientState, error)
+	Save(state *LightClientState) error
+}
+
+const state_snapshot_version uint8 = 1
+
+// borsh-go decodes a None pointer as a zero value, approvals are stored as an
+// explicit enum with the same encoding as Option<Signature>.
+type stored_approval struct {
+	Enum borsh.Enum `borsh_enum:"true"`
+	None struct{}
+	Some stored_signature
+}
+
+// borsh-go only writes enum variants that are structs
+type stored_signature struct {
+	Inner nearprimitive.Signature
+}
+
+type stored_block struct {
+	PrevBlockHash      nearprimitive.CryptoHash
+	NextBlockInnerHash nearprimitive.CryptoHash
+	InnerLite          nearprimitive.BlockHeaderInnerLiteView
+	InnerRestHash      nearprimitive.CryptoHash
+	NextBps            []nearprimitive.ValidatorStakeView
+	ApprovalsAfterNext []stored_approval
+}
+
+type stored_epoch struct {
+	EpochId        nearprimitive.CryptoHash
+	NextBpHash     nearprimitive.CryptoHash
+	BlockProducers []nearprimitive.ValidatorStakeView
+}
+
+type stored_conflicting_block struct {
+	BlockHash       nearprimitive.CryptoHash
+	Block           stored_block
+	ApprovalMessage []byte
+}
+
+type stored_double_signer struct {
+	AccountId     nearprimitive.AccountId
+	PublicKey     nearprimitive.PublicKey
+	SignerIndices [2]uint64
+}
+
+type stored_evidence struct {
+	Kind          uint8
+	First         stored_conflicting_block
+	Second        stored_conflicting_block
+	DoubleSigners []stored_double_signer
+}
+
+// Option<stored_evidence>
+type stored_optional_evidence struct {
+	Enum borsh.Enum `borsh_enum:"true"`
+	None struct{}
+	Some stored_evidence
+}
+
+type stored_state struct {
+	Version        uint8
+	Head           stored_block
+	HeightsToTrack uint64
+	HeadHistory    []stored_block
+	Epochs         []stored_epoch
+	Evidence       stored_optional_evidence
+}
+
+// Empty next bps are restored as nil, as parsed from the rpc
+func from_stored_validator_stakes(stored []nearprimitive.ValidatorStakeView) []nearprimitive.ValidatorStakeView {
+	if len(stored) == 0 {
+		return nil
+	}
+
+	return stored
+}
+
+func to_stored_approvals(approvals []*nearprimitive.Signature) []stored_approval {
+	res := []stored_approval{}
+
+	for _, approval := range approvals {
+		if approval == nil {
+			res = append(res, stored_approval{Enum: 0})
+		} else {
+			res = append(res, stored_approval{Enum: 1, Some: stored_signature{Inner: *approval}})
+		}
+	}
+
+	return res
+}
+
+func from_stored_approvals(stored []stored_approval) []*nearprimitive.Signature {
+	var res []*nearprimitive.Signature
+
+	for _, approval := range stored {
+		if approval.Enum == 0 {
+			res = append(res, nil)
+		} else {
+			signature := approval.Some.Inner
+			res = append(res, &signature)
+		}
+	}
+
+	return res
+}
+
+func to_stored_block(block_view nearprimitive.LightClientBlockView) stored_block {
+	return stored_block{
+		PrevBlockHash:      block_view.PrevBlockHash,
+		NextBlockInnerHash: block_view.NextBlockInnerHash,
+		InnerLite:          block_view.InnerLite,
+		InnerRestHash:      block_view.InnerRestHash,
+		NextBps:            block_view.NextBps,
+		ApprovalsAfterNext: to_stored_approvals(block_view.ApprovalsAfterNext),
+	}
+}
+
+func from_stored_block(sb stored_block) nearprimitive.LightClientBlockView {
+	return nearprimitive.LightClientBlockView{
+		PrevBlockHash:      sb.PrevBlockHash,
+		NextBlockInnerHash: sb.NextBlockInnerHash,
+		InnerLite:          sb.InnerLite,
+		InnerRestHash:      sb.InnerRestHash,
+		NextBps:            from_stored_validator_stakes(sb.NextBps),
+		ApprovalsAfterNext: from_stored_approvals(sb.ApprovalsAfterNext),
+	}
+}
+
+func to_stored_conflicting_block(cb ConflictingBlock) stored_conflicting_block {
+	return stored_conflicting_block{
+		BlockHash:       cb.BlockHash,
+		Block:           to_stored_block(cb.Block),
+		ApprovalMessage: cb.ApprovalMessage,
+	}
+}
+
+func from_stored_conflicting_block(scb stored_conflicting_block) ConflictingBlock {
+	return ConflictingBlock{
+		BlockHash:       scb.BlockHash,
+		Block:           from_stored_block(scb.Block),
+		ApprovalMessage: scb.ApprovalMessage,
+	}
+}
+
+func to_stored_evidence(evidence *EquivocationEvidence) stored_optional_evidence {
+	if evidence == nil {
+		return stored_optional_evidence{Enum: 0}
+	}
+
+	se := stored_evidence{
+		Kind:          uint8(evidence.Kind),
+		First:         to_stored_conflicting_block(evidence.First),
+		Second:        to_stored_conflicting_block(evidence.Second),
+		DoubleSigners: []stored_double_signer{},
+	}
+
+	for _, signer := range evidence.DoubleSigners {
+		se.DoubleSigners = append(se.DoubleSigners, stored_double_signer{
+			AccountId:     signer.AccountId,
+			PublicKey:     signer.PublicKey,
+			SignerIndices: [2]uint64{uint64(signer.SignerIndices[0]), uint64(signer.SignerIndices[1])},
+		})
+	}
+
+	return stored_optional_evidence{Enum: 1, Some: se}
+}
+
+func from_stored_evidence(stored stored_optional_evidence) *EquivocationEvidence {
+	if stored.Enum == 0 {
+		return nil
+	}
+
+	evidence := &EquivocationEvidence{
+		Kind:          ConflictKind(stored.Some.Kind),
+		First:         from_stored_conflicting_block(stored.Some.First),
+		Second:        from_stored_conflicting_block(stored.Some.Second),
+		DoubleSigners: []DoubleSigner{},
+	}
+
+	for _, signer := range stored.Some.DoubleSigners {
+		evidence.DoubleSigners = append(evidence.DoubleSigners, DoubleSigner{
+			AccountId:     signer.AccountId,
+			PublicKey:     signer.PublicKey,
+			SignerIndices: [2]int{int(signer.SignerIndices[0]), int(signer.SignerIndices[1])},
+		})
+	}
+
+	return evidence
+}
+
+// EncodeState borsh encodes a snapshot of state.
+func EncodeState(state *LightClientState) ([]byte, error) {
+	ss := stored_state{
+		Version:        state_snapshot_version,
+		Head:           to_stored_block(state.Head),
+		HeightsToTrack: state.HeightsToTrack,
+		HeadHistory:    []stored_block{},
+		Epochs:         []stored_epoch{},
+		Evidence:       to_stored_evidence(state.Evidence),
+	}
+
+	for _, head := range state.HeadHistory {
+		ss.HeadHistory = append(ss.HeadHistory, to_stored_block(head))
+	}
+
+	for _, epoch := range state.Epochs {
+		ss.Epochs = append(ss.Epochs, stored_epoch{
+			EpochId:        epoch.EpochId,
+			NextBpHash:     epoch.NextBpHash,
+			BlockProducers: epoch.BlockProducers,
+		})
+	}
+
+	data, err := borsh.Serialize(ss)
+	if err != nil {
+		return data, fmt.Errorf("Failed to serialize: %w", err)
+	}
+
+	return data, nil
+}
+
+// DecodeState decodes a snapshot produced by EncodeState.
+func DecodeState(data []byte) (*LightClientState, error) {
+	if len(data) == 0 || data[0] != state_snapshot_version {
+		return nil, fmt.Errorf("Unsupported state snapshot version")
+	}
+
+	ss := stored_state{}
+	err := borsh.Deserialize(&ss, data)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to deserialize: %w", err)
+	}
+
+	state := &LightClientState{
+		Head:           from_stored_block(ss.Head),
+		HeightsToTrack: ss.HeightsToTrack,
+		Evidence:       from_stored_evidence(ss.Evidence),
+	}
+
+	for _, head := range ss.HeadHistory {
+		state.HeadHistory = append(state.HeadHistory, from_stored_block(head))
+	}
+
+	for _, epoch := range ss.Epochs {
+		state.Epochs = append(state.Epochs, EpochState{
+			EpochId:        epoch.EpochId,
+			NextBpHash:     epoch.NextBpHash,
+			BlockProducers: from_stored_validator_stakes(epoch.BlockProducers),
+		})
+	}
+
+	return state, nil
+}
+
+// MemoryStore keeps the latest snapshot in memory.
+type MemoryStore struct {
+	mu   sync.Mutex
+	data []byte
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (ms *MemoryStore) Load() (*LightClientState, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if ms.data == nil {
+		return nil, nil
+	}
+
+	return DecodeState(ms.data)
+}
+
+func (ms *MemoryStore) Save(state *LightClientState) error {
+	data, err := EncodeState(state)
+	if err != nil {
+		return fmt.Errorf("Failed to encode state: %w", err)
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.data = data
+
+	return nil
+}
+
+// FileStore keeps the latest snapshot in a single file. Snapshots are written
+// to a temporary file in the same directory and renamed over the previous
+// one, so a crash leaves either the old or the new snapshot behind.
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+var _ Store = (*FileStore)(nil)
+
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func (fs *FileStore) Load() (*LightClientState, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	data, err := os.ReadFile(fs.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read state file: %w", err)
+	}
+
+	return DecodeState(data)
+}
+
+func (fs *FileStore) Save(state *LightClientState) error {
+	data, err := EncodeState(state)
+	if err != nil {
+		return fmt.Errorf("Failed to encode state: %w", err)
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	dir := filepath.Dir(fs.path)
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(fs.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("Failed to create temporary state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if close_err := tmp.Close(); err == nil {
+		err = close_err
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to write temporary state file: %w", err)
+	}
+
+	err = os.Rename(tmp.Name(), fs.path)
+	if err != nil {
+		return fmt.Errorf("Failed to replace state file: %w", err)
+	}
+
+	// Persist the rename itself
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("Failed to open state directory: %w", err)
+	}
+	defer d.Close()
+
+	err = d.Sync()
+	if err != nil {
+		return fmt.Errorf("Failed to sync state directory: %w", err)
+	}
+
+	return nil
+}
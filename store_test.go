@@ -0,0 +1,121 @@
+// Copyright © 2022, Electron Labs
+
+package light
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/electron-labs/near-light-client-go/mock"
+)
+
+func TestStateEncodingRoundTrip(t *testing.T) {
+	pre_epoch, err := GetClientBlockView(CLIENT_RESPONSE_PREVIOUS_EPOCH)
+	if err != nil {
+		t.Errorf("Failed to parse prev client block: %s", err)
+	}
+
+	lc := NewLightClient(mock.MockHostFunction{})
+	err = lc.Bootstrap(pre_epoch, 5)
+	if err != nil {
+		t.Errorf("Failed to bootstrap: %s", err)
+	}
+
+	state := lc.State()
+
+	data, err := EncodeState(state)
+	if err != nil {
+		t.Errorf("Failed to encode state: %s", err)
+	}
+
+	decoded_state, err := DecodeState(data)
+	if err != nil {
+		t.Errorf("Failed to decode state: %s", err)
+	}
+
+	if !reflect.DeepEqual(state, decoded_state) {
+		t.Errorf("state: %v\ndecoded_state: %v", state, decoded_state)
+	}
+
+	if data[0] != 1 {
+		t.Errorf("Unexpected snapshot version %d", data[0])
+	}
+
+	data[0] = 2
+	_, err = DecodeState(data)
+	if err == nil {
+		t.Errorf("Decoded an unknown snapshot version")
+	}
+}
+
+func TestMemoryStoreEmpty(t *testing.T) {
+	state, err := NewMemoryStore().Load()
+	if err != nil {
+		t.Errorf("Failed to load empty store: %s", err)
+	}
+
+	if state != nil {
+		t.Errorf("Empty store returned a state")
+	}
+
+	lc := NewLightClient(mock.MockHostFunction{}, WithStore(NewMemoryStore()))
+	resumed, err := lc.Resume()
+	if err != nil || resumed {
+		t.Errorf("Resumed from an empty store: %v %s", resumed, err)
+	}
+}
+
+func TestFileStoreResume(t *testing.T) {
+	pre_epoch, err := GetClientBlockView(CLIENT_RESPONSE_PREVIOUS_EPOCH)
+	if err != nil {
+		t.Errorf("Failed to parse prev client block: %s", err)
+	}
+
+	curr_epoch, err := GetClientBlockView(CLIENT_BLOCK_RESPONSE)
+	if err != nil {
+		t.Errorf("Failed to parse current client block: %s", err)
+	}
+
+	next_epoch, err := GetClientBlockView(CLIENT_BLOCK_RESPONSE_NEXT_BLOCK)
+	if err != nil {
+		t.Errorf("Failed to parse next client block: %s", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "state.borsh")
+
+	lc := NewLightClient(mock.MockHostFunction{}, WithStore(NewFileStore(path)))
+	err = lc.Bootstrap(pre_epoch, 5)
+	if err != nil {
+		t.Errorf("Failed to bootstrap: %s", err)
+	}
+
+	err = lc.ValidateAndUpdateHead(curr_epoch)
+	if err != nil {
+		t.Errorf("Failed to validate block: %s", err)
+	}
+
+	// Simulate a restart
+	restarted := NewLightClient(mock.MockHostFunction{}, WithStore(NewFileStore(path)))
+	resumed, err := restarted.Resume()
+	if err != nil {
+		t.Errorf("Failed to resume: %s", err)
+	}
+
+	if !resumed {
+		t.Errorf("Nothing to resume from")
+	}
+
+	if restarted.HeadHash() != lc.HeadHash() {
+		t.Errorf("Resumed at a different head")
+	}
+
+	if !reflect.DeepEqual(restarted.State(), lc.State()) {
+		t.Errorf("Resumed state differs from the saved one")
+	}
+
+	err = restarted.ValidateAndUpdateHead(next_epoch)
+	if err != nil {
+		t.Errorf("Failed to validate block after resuming: %s", err)
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright © 2022, Electron Labs
+
+package light
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/electron-labs/near-light-client-go/nearprimitive"
+)
+
+// BlockSource provides the light client blocks that follow a known block.
+type BlockSource interface {
+	// NextLightClientBlock returns the light client block that follows the
+	// block with hash last_block_hash, or nil if there is none yet.
+	NextLightClientBlock(ctx context.Context, last_block_hash nearprimitive.CryptoHash) (*nearprimitive.LightClientBlockView, error)
+}
+
+type SyncProgress struct {
+	Height        nearprimitive.BlockHeight
+	BlockHash     nearprimitive.CryptoHash
+	BlocksApplied uint64
+}
+
+// DefaultPollInterval is how long Run waits for new blocks once caught up.
+const DefaultPollInterval = 10 * time.Second
+
+type SyncerOption func(*Syncer)
+
+// WithProgress calls on_progress after every applied block.
+func WithProgress(on_progress func(SyncProgress)) SyncerOption {
+	return func(s *Syncer) {
+		s.on_progress = on_progress
+	}
+}
+
+func WithPollInterval(poll_interval time.Duration) SyncerOption {
+	return func(s *Syncer) {
+		s.poll_interval = poll_interval
+	}
+}
+
+// Syncer keeps a LightClient's head current by applying the blocks of a
+// BlockSource.
+type Syncer struct {
+	client        *LightClient
+	source        BlockSource
+	on_progress   func(SyncProgress)
+	poll_interval time.Duration
+}
+
+func NewSyncer(client *LightClient, source BlockSource, opts ...SyncerOption) *Syncer {
+	s := &Syncer{
+		client:        client,
+		source:        source,
+		poll_interval: DefaultPollInterval,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Sync fetches, validates and applies blocks until the source has no block
+// ahead of the head.
+func (s *Syncer) Sync(ctx context.Context) (SyncProgress, error) {
+	head_hash, head := s.client.HeadSnapshot()
+	progress := SyncProgress{
+		Height:    head.InnerLite.Height,
+		BlockHash: head_hash,
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return progress, err
+		}
+
+		head_hash, head := s.client.HeadSnapshot()
+
+		block_view, err := s.source.NextLightClientBlock(ctx, head_hash)
+		if err != nil {
+			return progress, fmt.Errorf("Failed to fetch next light client block: %w", err)
+		}
+
+		if block_view == nil || block_view.InnerLite.Height <= head.InnerLite.Height {
+			return progress, nil
+		}
+
+		err = s.client.ValidateAndUpdateHead(*block_view)
+		if err != nil {
+			return progress, fmt.Errorf("Failed to apply block %d: %w", block_view.InnerLite.Height, err)
+		}
+
+		head_hash, head = s.client.HeadSnapshot()
+		progress.Height = head.InnerLite.Height
+		progress.BlockHash = head_hash
+		progress.BlocksApplied++
+
+		if s.on_progress != nil {
+			s.on_progress(progress)
+		}
+	}
+}
+
+// Run syncs until ctx is cancelled, polling the source every poll interval
+// once caught up.
+func (s *Syncer) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.poll_interval)
+	defer ticker.Stop()
+
+	for {
+		_, err := s.Sync(ctx)
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// MemoryBlockSource serves a fixed chain of light client blocks, each one
+// following the previous one.
+type MemoryBlockSource struct {
+	mu     sync.Mutex
+	host   nearprimitive.HostFunction
+	blocks []nearprimitive.LightClientBlockView
+	index  map[nearprimitive.CryptoHash]int
+}
+
+var _ BlockSource = (*MemoryBlockSource)(nil)
+
+func NewMemoryBlockSource(h nearprimitive.HostFunction, blocks ...nearprimitive.LightClientBlockView) (*MemoryBlockSource, error) {
+	ms := &MemoryBlockSource{
+		host:  h,
+		index: map[nearprimitive.CryptoHash]int{},
+	}
+
+	for _, block_view := range blocks {
+		err := ms.Add(block_view)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ms, nil
+}
+
+// Add appends a block to the end of the chain.
+func (ms *MemoryBlockSource) Add(block_view nearprimitive.LightClientBlockView) error {
+	block_hash, err := block_view.CurrentBlockHash(ms.host)
+	if err != nil {
+		return fmt.Errorf("Failed to get block hash: %w", err)
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.index[block_hash] = len(ms.blocks)
+	ms.blocks = append(ms.blocks, block_view)
+
+	return nil
+}
+
+func (ms *MemoryBlockSource) NextLightClientBlock(ctx context.Context, last_block_hash nearprimitive.CryptoHash) (*nearprimitive.LightClientBlockView, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	i, ok := ms.index[last_block_hash]
+	if !ok {
+		return nil, fmt.Errorf("Unknown block %v", last_block_hash)
+	}
+
+	if i+1 >= len(ms.blocks) {
+		return nil, nil
+	}
+
+	block_view := ms.blocks[i+1]
+
+	return &block_view, nil
+}
@@ -0,0 +1,108 @@
+// Copyright © 2022, Electron Labs
+
+package light
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/electron-labs/near-light-client-go/mock"
+	"github.com/electron-labs/near-light-client-go/nearprimitive"
+)
+
+func new_synced_fixture(t *testing.T) (*LightClient, *MemoryBlockSource, []nearprimitive.LightClientBlockView) {
+	blocks := []nearprimitive.LightClientBlockView{}
+	for _, response := range []string{CLIENT_RESPONSE_PREVIOUS_EPOCH, CLIENT_BLOCK_RESPONSE, CLIENT_BLOCK_RESPONSE_NEXT_BLOCK} {
+		block_view, err := GetClientBlockView(response)
+		if err != nil {
+			t.Fatalf("Failed to parse client block: %s", err)
+		}
+		blocks = append(blocks, block_view)
+	}
+
+	h := mock.MockHostFunction{}
+
+	source, err := NewMemoryBlockSource(h, blocks...)
+	if err != nil {
+		t.Fatalf("Failed to create block source: %s", err)
+	}
+
+	lc := NewLightClient(h)
+	err = lc.Bootstrap(blocks[0], 10)
+	if err != nil {
+		t.Fatalf("Failed to bootstrap: %s", err)
+	}
+
+	return lc, source, blocks
+}
+
+func TestSyncerSync(t *testing.T) {
+	lc, source, blocks := new_synced_fixture(t)
+
+	reported := []SyncProgress{}
+	s := NewSyncer(lc, source, WithProgress(func(p SyncProgress) {
+		reported = append(reported, p)
+	}))
+
+	progress, err := s.Sync(context.Background())
+	if err != nil {
+		t.Errorf("Failed to sync: %s", err)
+	}
+
+	if progress.BlocksApplied != 2 || len(reported) != 2 {
+		t.Errorf("Expected 2 applied blocks, got %d (%d reported)", progress.BlocksApplied, len(reported))
+	}
+
+	if progress.Height != blocks[2].InnerLite.Height || lc.CurrentBlockHeight() != uint64(blocks[2].InnerLite.Height) {
+		t.Errorf("Did not sync to the last block: %d", lc.CurrentBlockHeight())
+	}
+
+	// Already caught up
+	progress, err = s.Sync(context.Background())
+	if err != nil {
+		t.Errorf("Failed to sync: %s", err)
+	}
+
+	if progress.BlocksApplied != 0 {
+		t.Errorf("Applied blocks while caught up: %d", progress.BlocksApplied)
+	}
+}
+
+func TestSyncerCancellation(t *testing.T) {
+	lc, source, blocks := new_synced_fixture(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewSyncer(lc, source).Sync(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	if lc.CurrentBlockHeight() != uint64(blocks[0].InnerLite.Height) {
+		t.Errorf("Head moved after cancellation")
+	}
+}
+
+func TestSyncerRun(t *testing.T) {
+	lc, source, blocks := new_synced_fixture(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s := NewSyncer(lc, source, WithPollInterval(time.Millisecond), WithProgress(func(p SyncProgress) {
+		if p.Height == blocks[2].InnerLite.Height {
+			cancel()
+		}
+	}))
+
+	err := s.Run(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	if lc.CurrentBlockHeight() != uint64(blocks[2].InnerLite.Height) {
+		t.Errorf("Did not sync to the last block: %d", lc.CurrentBlockHeight())
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright © 2022, Electron Labs
+
+package light
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/electron-labs/near-light-client-go/nearprimitive"
+)
+
+// Helpers to build light client blocks signed by locally generated block
+// producers, for scenarios the mainnet fixtures don't cover.
+
+const synthetic_genesis_timestamp = uint64(1648794682287664503)
+
+var (
+	synthetic_epoch_0 = nearprimitive.CryptoHash{0xe0}
+	synthetic_epoch_1 = nearprimitive.CryptoHash{0xe1}
+	synthetic_epoch_2 = nearprimitive.CryptoHash{0xe2}
+)
+
+type synthetic_validator struct {
+	private_key ed25519.PrivateKey
+	stake_view  nearprimitive.ValidatorStakeView
+}
+
+func new_synthetic_validators(t *testing.T, prefix string, n int) []synthetic_validator {
+	validators := []synthetic_validator{}
+
+	for i := 0; i < n; i++ {
+		account_id := fmt.Sprintf("%s%d.near", prefix, i)
+		seed := sha256.Sum256([]byte(account_id))
+		private_key := ed25519.NewKeyFromSeed(seed[:])
+
+		public_key := nearprimitive.PublicKey{}
+		err := public_key.TryFromRaw(private_key.Public().(ed25519.PublicKey))
+		if err != nil {
+			t.Fatalf("Failed to create public key: %s", err)
+		}
+
+		vs := nearprimitive.NewValidatorStakeViewV1(nearprimitive.AccountId(account_id), public_key, nearprimitive.BalanceFromUint64(1000000))
+
+		validators = append(validators, synthetic_validator{private_key: private_key, stake_view: vs})
+	}
+
+	return validators
+}
+
+func synthetic_stake_views(validators []synthetic_validator) []nearprimitive.ValidatorStakeView {
+	res := []nearprimitive.ValidatorStakeView{}
+	for _, v := range validators {
+		res = append(res, v.stake_view)
+	}
+
+	return res
+}
+
+func new_synthetic_stake_views(t *testing.T, prefix string, n int) []nearprimitive.ValidatorStakeView {
+	return synthetic_stake_views(new_synthetic_validators(t, prefix, n))
+}
+
+// new_synthetic_client bootstraps a client from a checkpoint at height 100 of
+// synthetic_epoch_0 that hands over to validators in synthetic_epoch_1.
+func new_synthetic_client(t *testing.T, h nearprimitive.HostFunction, validators []synthetic_validator, opts ...ClientOption) *LightClient {
+	checkpoint := new_synthetic_block(t, h, 100, synthetic_epoch_0, synthetic_epoch_1, synthetic_stake_views(validators), 0)
+
+	lc := NewLightClient(h, opts...)
+	err := lc.Bootstrap(checkpoint, 10)
+	if err != nil {
+		t.Fatalf("Failed to bootstrap: %s", err)
+	}
+
+	return lc
+}
+
+// new_synthetic_block creates an unsigned block; salt makes otherwise equal
+// blocks hash differently.
+func new_synthetic_block(t *testing.T, h nearprimitive.HostFunction, height nearprimitive.BlockHeight, epoch_id nearprimitive.CryptoHash, next_epoch_id nearprimitive.CryptoHash, next_bps []nearprimitive.ValidatorStakeView, salt byte) nearprimitive.LightClientBlockView {
+	block_view := nearprimitive.LightClientBlockView{
+		PrevBlockHash:      nearprimitive.CryptoHash{salt, 1},
+		NextBlockInnerHash: nearprimitive.CryptoHash{salt, 2},
+		InnerRestHash:      nearprimitive.CryptoHash{salt, 3},
+		NextBps:            next_bps,
+	}
+
+	block_view.InnerLite = nearprimitive.BlockHeaderInnerLiteView{
+		Height:           height,
+		EpochId:          epoch_id,
+		NextEpochId:      next_epoch_id,
+		PrevStateRoot:    nearprimitive.CryptoHash{salt, 4},
+		OutcomeRoot:      nearprimitive.CryptoHash{salt, 5},
+		Timestamp:        synthetic_genesis_timestamp + uint64(height)*1000000000,
+		TimestampNanosec: synthetic_genesis_timestamp + uint64(height)*1000000000,
+		BlockMerkleRoot:  nearprimitive.CryptoHash{salt, 6},
+	}
+
+	if len(next_bps) > 0 {
+		next_bp_hash, err := next_bps_hash(h, next_bps)
+		if err != nil {
+			t.Fatalf("Failed to hash next bps: %s", err)
+		}
+		block_view.InnerLite.NextBpHash = next_bp_hash
+	}
+
+	return block_view
+}
+
+// synthetic_signer is implemented by test hosts whose Verify doesn't accept
+// plain ed25519 signatures.
+type synthetic_signer interface {
+	Sign(private_key ed25519.PrivateKey, message []byte) []byte
+}
+
+// sign_synthetic_block fills ApprovalsAfterNext, validators[i] signs if
+// signs(i) is true.
+func sign_synthetic_block(t *testing.T, h nearprimitive.HostFunction, block_view *nearprimitive.LightClientBlockView, validators []synthetic_validator, signs func(i int) bool) {
+	_, _, approval_message, err := reconstruct_light_client_block_view_fields(h, *block_view)
+	if err != nil {
+		t.Fatalf("Failed to build approval message: %s", err)
+	}
+
+	sign_synthetic_approvals(t, h, block_view, approval_message, validators, signs)
+}
+
+// sign_synthetic_approvals is sign_synthetic_block for any approval message.
+func sign_synthetic_approvals(t *testing.T, h nearprimitive.HostFunction, block_view *nearprimitive.LightClientBlockView, approval_message []byte, validators []synthetic_validator, signs func(i int) bool) {
+	sign := ed25519.Sign
+	if signer, ok := h.(synthetic_signer); ok {
+		sign = signer.Sign
+	}
+
+	block_view.ApprovalsAfterNext = []*nearprimitive.Signature{}
+	for i, v := range validators {
+		if !signs(i) {
+			block_view.ApprovalsAfterNext = append(block_view.ApprovalsAfterNext, nil)
+			continue
+		}
+
+		signature := &nearprimitive.Signature{}
+		err := signature.TryFromRaw(sign(v.private_key, approval_message))
+		if err != nil {
+			t.Fatalf("Failed to create signature: %s", err)
+		}
+		block_view.ApprovalsAfterNext = append(block_view.ApprovalsAfterNext, signature)
+	}
+}
+
+func all_sign(i int) bool {
+	return true
+}
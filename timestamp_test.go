@@ -0,0 +1,109 @@
+// Copyright © 2022, Electron Labs
+
+package light
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/electron-labs/near-light-client-go/mock"
+	"github.com/electron-labs/near-light-client-go/nearprimitive"
+)
+
+func TestValidateLightBlockTimestamp(t *testing.T) {
+	h := mock.MockHostFunction{}
+	validators := new_synthetic_validators(t, "bp", 4)
+	next_validators := new_synthetic_stake_views(t, "next", 4)
+
+	head := new_synthetic_block(t, h, 100, synthetic_epoch_0, synthetic_epoch_1, synthetic_stake_views(validators), 0)
+	producers := map[nearprimitive.CryptoHash][]nearprimitive.ValidatorStakeView{
+		synthetic_epoch_1: synthetic_stake_views(validators),
+	}
+
+	for _, timestamp := range []uint64{head.InnerLite.Timestamp, head.InnerLite.Timestamp - 1} {
+		block_view := new_synthetic_block(t, h, 110, synthetic_epoch_1, synthetic_epoch_2, next_validators, 1)
+		block_view.InnerLite.Timestamp = timestamp
+		block_view.InnerLite.TimestampNanosec = timestamp
+		sign_synthetic_block(t, h, &block_view, validators, all_sign)
+
+		err := ValidateLightBlock(h, &head, &block_view, producers)
+		if !errors.Is(err, ErrTimestampNotAhead) {
+			t.Errorf("Expected ErrTimestampNotAhead, got %v", err)
+		}
+	}
+
+	block_view := new_synthetic_block(t, h, 110, synthetic_epoch_1, synthetic_epoch_2, next_validators, 1)
+	sign_synthetic_block(t, h, &block_view, validators, all_sign)
+
+	block_timestamp := time.Unix(0, int64(block_view.InnerLite.Timestamp))
+	clock_at := func(t time.Time) Clock {
+		return func() time.Time { return t }
+	}
+
+	err := ValidateLightBlock(h, &head, &block_view, producers, WithClock(clock_at(block_timestamp.Add(-DefaultMaxFutureDrift))))
+	if err != nil {
+		t.Errorf("Failed to validate block: %s", err)
+	}
+
+	err = ValidateLightBlock(h, &head, &block_view, producers, WithClock(clock_at(block_timestamp.Add(-DefaultMaxFutureDrift-1))))
+	if !errors.Is(err, ErrTimestampInFuture) {
+		t.Errorf("Expected ErrTimestampInFuture, got %v", err)
+	}
+
+	err = ValidateLightBlock(h, &head, &block_view, producers, WithClock(clock_at(block_timestamp.Add(-time.Hour))), WithMaxFutureDrift(time.Hour))
+	if err != nil {
+		t.Errorf("Failed to validate block: %s", err)
+	}
+
+	err = ValidateLightBlock(h, &head, &block_view, producers, WithClock(clock_at(block_timestamp)), WithMaxFutureDrift(0))
+	if err != nil {
+		t.Errorf("Failed to validate block: %s", err)
+	}
+
+	// Block from a long time ago against the real clock
+	err = ValidateLightBlock(h, &head, &block_view, producers)
+	if err != nil {
+		t.Errorf("Failed to validate block: %s", err)
+	}
+
+	// Timestamps beyond int64 nanoseconds must not wrap around to the past
+	for _, timestamp := range []uint64{math.MaxInt64 + 1, math.MaxUint64} {
+		overflow := new_synthetic_block(t, h, 110, synthetic_epoch_1, synthetic_epoch_2, next_validators, 1)
+		overflow.InnerLite.Timestamp = timestamp
+		overflow.InnerLite.TimestampNanosec = timestamp
+		sign_synthetic_block(t, h, &overflow, validators, all_sign)
+
+		err = ValidateLightBlock(h, &head, &overflow, producers, WithMaxFutureDrift(time.Duration(math.MaxInt64)))
+		if !errors.Is(err, ErrTimestampInFuture) {
+			t.Errorf("Expected ErrTimestampInFuture for %d, got %v", timestamp, err)
+		}
+	}
+}
+
+func TestLightClientRejectsFutureBlocks(t *testing.T) {
+	h := mock.MockHostFunction{}
+	validators := new_synthetic_validators(t, "bp", 4)
+	next_validators := new_synthetic_stake_views(t, "next", 4)
+
+	// The clock stands at height 105
+	now := time.Unix(0, int64(synthetic_genesis_timestamp+105*1000000000))
+	lc := new_synthetic_client(t, h, validators, WithValidateOptions(WithClock(func() time.Time { return now }), WithMaxFutureDrift(2*time.Second)))
+
+	future := new_synthetic_block(t, h, 110, synthetic_epoch_1, synthetic_epoch_2, next_validators, 1)
+	sign_synthetic_block(t, h, &future, validators, all_sign)
+
+	err := lc.ValidateAndUpdateHead(future)
+	if !errors.Is(err, ErrTimestampInFuture) {
+		t.Errorf("Expected ErrTimestampInFuture, got %v", err)
+	}
+
+	block_view := new_synthetic_block(t, h, 107, synthetic_epoch_1, synthetic_epoch_2, next_validators, 1)
+	sign_synthetic_block(t, h, &block_view, validators, all_sign)
+
+	err = lc.ValidateAndUpdateHead(block_view)
+	if err != nil {
+		t.Errorf("Failed to validate block: %s", err)
+	}
+}
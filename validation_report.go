@@ -0,0 +1,66 @@
+// Copyright © 2022, Electron Labs
+
+package light
+
+import (
+	"fmt"
+
+	"github.com/electron-labs/near-light-client-go/nearprimitive"
+	num "github.com/shabbyrobe/go-num"
+)
+
+type ApprovalStatus uint8
+
+const (
+	// The block producer didn't approve the block
+	ApprovalMissing ApprovalStatus = iota
+	ApprovalSigned
+	// The approval doesn't verify against the block producer's key
+	ApprovalInvalid
+)
+
+func (s ApprovalStatus) String() string {
+	switch s {
+	case ApprovalMissing:
+		return "missing"
+	case ApprovalSigned:
+		return "signed"
+	case ApprovalInvalid:
+		return "invalid"
+	}
+
+	return fmt.Sprintf("ApprovalStatus(%d)", uint8(s))
+}
+
+// ValidatorApproval is the approval of a single block producer, in the order
+// of the block's epoch producers.
+type ValidatorApproval struct {
+	AccountId nearprimitive.AccountId
+	PublicKey nearprimitive.PublicKey
+	Stake     num.U128
+	Status    ApprovalStatus
+}
+
+// ValidationReport is what ValidateLightBlockDetailed found out about a block.
+// It is filled as far as validation got, also when it failed.
+type ValidationReport struct {
+	CurrentBlockHash nearprimitive.CryptoHash
+	NextBlockHash    nearprimitive.CryptoHash
+	ApprovalMessage  []byte
+	TotalStake       num.U128
+	// Stake of the block producers with a valid approval
+	ApprovedStake  num.U128
+	Approvals      []ValidatorApproval
+	NextBpsPresent bool
+	// Only meaningful if NextBpsPresent
+	NextBpHashMatches bool
+}
+
+// ApprovalRatio is ApprovedStake / TotalStake, zero if the total is unknown.
+func (r *ValidationReport) ApprovalRatio() float64 {
+	if r.TotalStake.IsZero() {
+		return 0
+	}
+
+	return r.ApprovedStake.AsFloat64() / r.TotalStake.AsFloat64()
+}
@@ -0,0 +1,137 @@
+// Copyright © 2022, Electron Labs
+
+package light
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"testing"
+
+	"github.com/electron-labs/near-light-client-go/mock"
+	"github.com/electron-labs/near-light-client-go/nearprimitive"
+)
+
+func TestValidateLightBlockDetailed(t *testing.T) {
+	pre_epoch, err := GetClientBlockView(CLIENT_RESPONSE_PREVIOUS_EPOCH)
+	if err != nil {
+		t.Errorf("Failed to parse prev client block: %s", err)
+	}
+
+	curr_epoch, err := GetClientBlockView(CLIENT_BLOCK_RESPONSE)
+	if err != nil {
+		t.Errorf("Failed to parse current client block: %s", err)
+	}
+
+	h := mock.MockHostFunction{}
+	producers := map[nearprimitive.CryptoHash][]nearprimitive.ValidatorStakeView{
+		pre_epoch.InnerLite.NextEpochId: pre_epoch.NextBps,
+	}
+
+	report, err := ValidateLightBlockDetailed(h, &pre_epoch, &curr_epoch, producers)
+	if err != nil {
+		t.Fatalf("Failed to validate block: %s", err)
+	}
+
+	curr_hash, err := curr_epoch.CurrentBlockHash(h)
+	if err != nil {
+		t.Fatalf("Failed to hash block: %s", err)
+	}
+
+	_, next_hash, approval_message, err := reconstruct_light_client_block_view_fields(h, curr_epoch)
+	if err != nil {
+		t.Fatalf("Failed to reconstruct block fields: %s", err)
+	}
+
+	if report.CurrentBlockHash != curr_hash || report.NextBlockHash != next_hash || !bytes.Equal(report.ApprovalMessage, approval_message) {
+		t.Errorf("Unexpected reconstructed fields")
+	}
+
+	if !report.NextBpsPresent || !report.NextBpHashMatches {
+		t.Errorf("Next bps should be present and match")
+	}
+
+	if len(report.Approvals) != len(curr_epoch.ApprovalsAfterNext) {
+		t.Fatalf("Expected %d approvals, got %d", len(curr_epoch.ApprovalsAfterNext), len(report.Approvals))
+	}
+
+	for i, approval := range report.Approvals {
+		expected := ApprovalSigned
+		if curr_epoch.ApprovalsAfterNext[i] == nil {
+			expected = ApprovalMissing
+		}
+
+		if approval.Status != expected {
+			t.Errorf("Approval %d from %s: expected %s, got %s", i, approval.AccountId, expected, approval.Status)
+		}
+	}
+
+	if ratio := report.ApprovalRatio(); ratio <= 2.0/3.0 || ratio > 1 {
+		t.Errorf("Unexpected approval ratio %f", ratio)
+	}
+
+	err = ValidateLightBlock(h, &pre_epoch, &curr_epoch, producers)
+	if err != nil {
+		t.Errorf("Failed to validate block: %s", err)
+	}
+}
+
+func TestValidateLightBlockDetailedFailure(t *testing.T) {
+	h := mock.MockHostFunction{}
+	validators := new_synthetic_validators(t, "bp", 4)
+	next_validators := new_synthetic_stake_views(t, "next", 4)
+
+	head := new_synthetic_block(t, h, 100, synthetic_epoch_0, synthetic_epoch_1, synthetic_stake_views(validators), 0)
+	producers := map[nearprimitive.CryptoHash][]nearprimitive.ValidatorStakeView{
+		synthetic_epoch_1: synthetic_stake_views(validators),
+	}
+
+	block_view := new_synthetic_block(t, h, 110, synthetic_epoch_1, synthetic_epoch_2, next_validators, 1)
+	sign_synthetic_block(t, h, &block_view, validators, func(i int) bool { return i != 1 })
+
+	_, _, approval_message, err := reconstruct_light_client_block_view_fields(h, block_view)
+	if err != nil {
+		t.Fatalf("Failed to build approval message: %s", err)
+	}
+
+	forged_signature := &nearprimitive.Signature{}
+	err = forged_signature.TryFromRaw(ed25519.Sign(validators[0].private_key, approval_message))
+	if err != nil {
+		t.Fatalf("Failed to create signature: %s", err)
+	}
+	block_view.ApprovalsAfterNext[3] = forged_signature
+
+	report, err := ValidateLightBlockDetailed(h, &head, &block_view, producers)
+	if !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected ErrInvalidSignature, got %v", err)
+	}
+
+	expected := []ApprovalStatus{ApprovalSigned, ApprovalMissing, ApprovalSigned, ApprovalInvalid}
+	if len(report.Approvals) != len(expected) {
+		t.Fatalf("Expected %d approvals, got %d", len(expected), len(report.Approvals))
+	}
+
+	for i, approval := range report.Approvals {
+		if approval.Status != expected[i] {
+			t.Errorf("Approval %d: expected %s, got %s", i, expected[i], approval.Status)
+		}
+
+		if approval.AccountId != validators[i].stake_view.V1.AccountId {
+			t.Errorf("Approval %d from unexpected account %s", i, approval.AccountId)
+		}
+	}
+
+	if report.ApprovalRatio() != 0.5 {
+		t.Errorf("Expected an approval ratio of 0.5, got %f", report.ApprovalRatio())
+	}
+
+	// The report is filled even when the block's epoch is unknown
+	report, err = ValidateLightBlockDetailed(h, &head, &block_view, nil)
+	if !errors.Is(err, ErrUnknownEpoch) {
+		t.Errorf("Expected ErrUnknownEpoch, got %v", err)
+	}
+
+	if !report.NextBpsPresent || !report.NextBpHashMatches || len(report.Approvals) != 0 || report.ApprovalRatio() != 0 {
+		t.Errorf("Unexpected report for an unknown epoch")
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright © 2022, Electron Labs
+
+package light
+
+import (
+	"encoding/json"
+
+	"github.com/electron-labs/near-light-client-go/nearprimitive"
+)
+
+// VerifiedOutcome is an execution outcome whose inclusion in a trusted head
+// was proven. It is only produced by VerifyOutcome and the LightClient's
+// proof verification, a zero VerifiedOutcome holds nothing.
+type VerifiedOutcome struct {
+	id           nearprimitive.CryptoHash
+	outcome      nearprimitive.ExecutionOutcomeView
+	block_height nearprimitive.BlockHeight
+	block_hash   nearprimitive.CryptoHash
+}
+
+// VerifyOutcome is VerifyInclusion returning the proven outcome as a
+// VerifiedOutcome.
+func VerifyOutcome(h nearprimitive.HostFunction, head nearprimitive.LightClientBlockView, proof NearTxResult) (VerifiedOutcome, error) {
+	block_hash, err := verify_inclusion(h, head, proof)
+	if err != nil {
+		return VerifiedOutcome{}, err
+	}
+
+	return VerifiedOutcome{
+		id:           proof.OutcomeProof.Id,
+		outcome:      proof.OutcomeProof.Outcome,
+		block_height: proof.BlockHeaderLite.InnerLite.Height,
+		block_hash:   block_hash,
+	}, nil
+}
+
+// Id is the hash of the transaction or the id of the receipt.
+func (vo VerifiedOutcome) Id() nearprimitive.CryptoHash {
+	return vo.id
+}
+
+func (vo VerifiedOutcome) ExecutorId() nearprimitive.AccountId {
+	return vo.outcome.ExecutorId
+}
+
+func (vo VerifiedOutcome) Logs() []string {
+	return append([]string{}, vo.outcome.Logs...)
+}
+
+func (vo VerifiedOutcome) ReceiptIds() []nearprimitive.CryptoHash {
+	return append([]nearprimitive.CryptoHash{}, vo.outcome.ReceiptIds...)
+}
+
+func (vo VerifiedOutcome) GasBurnt() nearprimitive.Gas {
+	return vo.outcome.GasBurnt
+}
+
+func (vo VerifiedOutcome) TokensBurnt() nearprimitive.Balance {
+	return vo.outcome.TokensBurnt
+}
+
+// StatusType is the kind of the execution status, covered by the proof.
+func (vo VerifiedOutcome) StatusType() nearprimitive.ExecutionStatusType {
+	return vo.outcome.Status.Type()
+}
+
+// SuccessValue returns the value the execution returned, if it succeeded with
+// one.
+func (vo VerifiedOutcome) SuccessValue() ([]byte, bool) {
+	if vo.outcome.Status.Type() != nearprimitive.StatusSuccessValue {
+		return nil, false
+	}
+
+	return append([]byte{}, vo.outcome.Status.SuccessValue.Inner...), true
+}
+
+// SuccessReceiptId returns the receipt the execution was converted to, if it
+// succeeded with one.
+func (vo VerifiedOutcome) SuccessReceiptId() (nearprimitive.CryptoHash, bool) {
+	if vo.outcome.Status.Type() != nearprimitive.StatusSuccessReceiptId {
+		return nearprimitive.CryptoHash{}, false
+	}
+
+	return vo.outcome.Status.SuccessReceiptId.Inner, true
+}
+
+// UnverifiedFailure returns why the execution failed, if it did. Only the
+// failure itself is proven: nearcore's outcome hash omits the error, so its
+// details are whatever the rpc node reported. Errors that don't decode are
+// returned empty, see UnverifiedUnparsedFailure.
+func (vo VerifiedOutcome) UnverifiedFailure() (nearprimitive.TxExecutionError, bool) {
+	if vo.outcome.Status.Type() != nearprimitive.StatusFailure {
+		return nearprimitive.TxExecutionError{}, false
+	}
+
+	return vo.outcome.Status.Failure, true
+}
+
+// UnverifiedUnparsedFailure returns the json of the error the rpc node
+// reported, if the execution failed with one that doesn't decode as a
+// nearprimitive.TxExecutionError.
+func (vo VerifiedOutcome) UnverifiedUnparsedFailure() (json.RawMessage, bool) {
+	if vo.outcome.Status.Type() != nearprimitive.StatusFailure || vo.outcome.Status.UnparsedFailure == nil {
+		return nil, false
+	}
+
+	return append(json.RawMessage{}, vo.outcome.Status.UnparsedFailure...), true
+}
+
+// BlockHeight is the height of the block the outcome was proven in.
+func (vo VerifiedOutcome) BlockHeight() nearprimitive.BlockHeight {
+	return vo.block_height
+}
+
+// BlockHash is the hash of the block the outcome was proven in.
+func (vo VerifiedOutcome) BlockHash() nearprimitive.CryptoHash {
+	return vo.block_hash
+}
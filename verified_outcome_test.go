@@ -0,0 +1,162 @@
+// Copyright © 2022, Electron Labs
+
+package light
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	base58 "github.com/btcsuite/btcutil/base58"
+	"github.com/electron-labs/near-light-client-go/mock"
+	"github.com/electron-labs/near-light-client-go/nearprimitive"
+	borsh "github.com/near/borsh-go"
+)
+
+func TestVerifyOutcome(t *testing.T) {
+	h := mock.MockHostFunction{}
+
+	head, err := GetClientBlockView(LIGHT_CLIENT_BLOCK)
+	if err != nil {
+		t.Fatalf("Failed to parse light client block: %s", err)
+	}
+
+	proof, err := GetNearTxResult(EXECUTION_OUTCOME)
+	if err != nil {
+		t.Fatalf("Failed to parse proof: %s", err)
+	}
+
+	outcome, err := VerifyOutcome(h, head, proof)
+	if err != nil {
+		t.Fatalf("Failed to verify outcome: %s", err)
+	}
+
+	receipt_id := nearprimitive.CryptoHash{}
+	receipt_id.TryFromRaw(base58.Decode("AYDomG3TpmssBCp2F15qcos9CtharnDHHefKdxToH8Uf"))
+
+	if outcome.Id() != proof.OutcomeProof.Id || outcome.ExecutorId() != "partht.testnet" || len(outcome.Logs()) != 0 {
+		t.Errorf("Unexpected outcome %v", outcome)
+	}
+
+	if outcome.GasBurnt() != 2428117762192 || outcome.TokensBurnt().String() != "242811776219200000000" {
+		t.Errorf("Unexpected burnt gas %d and tokens %s", outcome.GasBurnt(), outcome.TokensBurnt())
+	}
+
+	receipt_ids := outcome.ReceiptIds()
+	if len(receipt_ids) != 1 || receipt_ids[0] != receipt_id {
+		t.Errorf("Unexpected receipt ids %v", receipt_ids)
+	}
+
+	// Getters return copies
+	receipt_ids[0] = nearprimitive.CryptoHash{}
+	if outcome.ReceiptIds()[0] != receipt_id {
+		t.Errorf("Receipt ids were modified")
+	}
+
+	success_receipt_id, ok := outcome.SuccessReceiptId()
+	if outcome.StatusType() != nearprimitive.StatusSuccessReceiptId || !ok || success_receipt_id != receipt_id {
+		t.Errorf("Unexpected status %d %v", outcome.StatusType(), success_receipt_id)
+	}
+
+	if _, ok := outcome.SuccessValue(); ok {
+		t.Errorf("Unexpected success value")
+	}
+
+	if _, ok := outcome.UnverifiedFailure(); ok {
+		t.Errorf("Unexpected failure")
+	}
+
+	if outcome.BlockHeight() != 102367480 || outcome.BlockHash() != proof.OutcomeProof.BlockHash {
+		t.Errorf("Unexpected block %d %v", outcome.BlockHeight(), outcome.BlockHash())
+	}
+
+	proof.OutcomeProof.Outcome.GasBurnt++
+	outcome, err = VerifyOutcome(h, head, proof)
+	if err == nil || outcome.ExecutorId() != "" {
+		t.Errorf("Verified a tampered outcome: %v", outcome)
+	}
+}
+
+func TestVerifiedOutcomeSuccessValue(t *testing.T) {
+	outcome := VerifiedOutcome{}
+	outcome.outcome.Status.Enum = borsh.Enum(nearprimitive.StatusSuccessValue)
+	outcome.outcome.Status.SuccessValue.Inner = []byte(`{"ok":true}`)
+
+	value, ok := outcome.SuccessValue()
+	if !ok || !bytes.Equal(value, []byte(`{"ok":true}`)) {
+		t.Errorf("Unexpected success value %s", value)
+	}
+
+	value[0] = 0
+	if outcome.outcome.Status.SuccessValue.Inner[0] != '{' {
+		t.Errorf("Success value was modified")
+	}
+
+	if _, ok := outcome.SuccessReceiptId(); ok {
+		t.Errorf("Unexpected success receipt id")
+	}
+}
+
+func TestVerifiedOutcomeUnverifiedFailure(t *testing.T) {
+	outcome := VerifiedOutcome{}
+	err := json.Unmarshal([]byte(`{"Failure": {"InvalidTxError": {"InvalidNonce": {"tx_nonce": 5, "ak_nonce": 6}}}}`), &outcome.outcome.Status)
+	if err != nil {
+		t.Fatalf("Failed to parse status: %s", err)
+	}
+
+	if outcome.StatusType() != nearprimitive.StatusFailure {
+		t.Errorf("Unexpected status %d", outcome.StatusType())
+	}
+
+	failure, ok := outcome.UnverifiedFailure()
+	if !ok || failure.InvalidTxError.InvalidNonce.TxNonce != 5 {
+		t.Errorf("Unexpected failure %v", failure)
+	}
+
+	if _, ok := outcome.UnverifiedUnparsedFailure(); ok {
+		t.Errorf("Unexpected unparsed failure")
+	}
+
+	if _, ok := outcome.SuccessValue(); ok {
+		t.Errorf("Unexpected success value")
+	}
+}
+
+func TestVerifyOutcomeUnparsedFailure(t *testing.T) {
+	h := mock.MockHostFunction{}
+
+	head, err := GetClientBlockView(LIGHT_CLIENT_BLOCK)
+	if err != nil {
+		t.Fatalf("Failed to parse light client block: %s", err)
+	}
+
+	head.InnerLite.BlockMerkleRoot.TryFromRaw(base58.Decode(FAILED_RECEIPT_OUTCOME_BLOCK_MERKLE_ROOT))
+
+	proof, err := GetNearTxResult(FAILED_RECEIPT_OUTCOME)
+	if err != nil {
+		t.Fatalf("Failed to parse proof: %s", err)
+	}
+
+	outcome, err := VerifyOutcome(h, head, proof)
+	if err != nil {
+		t.Fatalf("Failed to verify outcome: %s", err)
+	}
+
+	if outcome.StatusType() != nearprimitive.StatusFailure {
+		t.Errorf("Unexpected status %d", outcome.StatusType())
+	}
+
+	if failure, ok := outcome.UnverifiedFailure(); !ok || failure != (nearprimitive.TxExecutionError{}) {
+		t.Errorf("Unexpected failure %v", failure)
+	}
+
+	unparsed, ok := outcome.UnverifiedUnparsedFailure()
+	if !ok || !bytes.Contains(unparsed, []byte("DelegateActionExpired")) {
+		t.Errorf("Unexpected unparsed failure %s", unparsed)
+	}
+
+	unparsed[0] = 0
+	if again, _ := outcome.UnverifiedUnparsedFailure(); again[0] != '{' {
+		t.Errorf("Unparsed failure was modified")
+	}
+}
@@ -30,18 +30,21 @@ func calculate_merklelization_hashes(h nearprimitive.HostFunction, eo nearprimit
 		return res, fmt.Errorf("Failed to serialize tokens burnt: %s", err)
 	}
 
-	ser_tokens_burnt = append(ser_tokens_burnt[8:], ser_tokens_burnt[:8]...)
-
 	ser_executor_id, err := borsh.Serialize(eo.ExecutorId)
 	if err != nil {
 		return res, fmt.Errorf("Failed to serialize executor id: %s", err)
 	}
 
+	ser_status, err := eo.Status.SerializePartial()
+	if err != nil {
+		return res, fmt.Errorf("Failed to serialize status: %w", err)
+	}
+
 	logs_payload = append(logs_payload, ser_recipet_ids...)
 	logs_payload = append(logs_payload, ser_gas_burnt...)
 	logs_payload = append(logs_payload, ser_tokens_burnt...)
 	logs_payload = append(logs_payload, ser_executor_id...)
-	logs_payload = append(logs_payload, eo.Status...)
+	logs_payload = append(logs_payload, ser_status...)
 
 	first_elem_merkelization_hashes := h.Sha256(logs_payload)
 
@@ -58,7 +61,7 @@ func calculate_execution_outcome_hash(h nearprimitive.HostFunction, eo nearprimi
 	res := &nearprimitive.CryptoHash{}
 	merkelization_hashes, err := calculate_merklelization_hashes(h, eo)
 	if err != nil {
-		return *res, fmt.Errorf("Failed to calculate merkelization hashes: %s", err)
+		return *res, fmt.Errorf("Failed to calculate merkelization hashes: %w", err)
 	}
 
 	pack_merkelization_hash := []byte{}
@@ -88,30 +91,90 @@ func calculate_execution_outcome_hash(h nearprimitive.HostFunction, eo nearprimi
 func ValidateTransaction(h nearprimitive.HostFunction, op nearprimitive.OutcomeProof, orp nearprimitive.MerklePath, ebor nearprimitive.CryptoHash) error {
 	execution_outcome_hash, err := calculate_execution_outcome_hash(h, op.Outcome, op.Id)
 	if err != nil {
-		return fmt.Errorf("Failed to calculate execution outcome hash: %s", err)
+		return fmt.Errorf("Failed to calculate execution outcome hash: %w", err)
 	}
 
 	shard_outcome_root, err := compute_root_from_path(h, op.Proof, nearprimitive.MerkleHash(execution_outcome_hash))
 	if err != nil {
-		return fmt.Errorf("Failed to compute root from path: %s", err)
+		return fmt.Errorf("Failed to compute root from path: %w", err)
 	}
 
 	ser_shard_outcome_root, err := borsh.Serialize(shard_outcome_root)
 	if err != nil {
-		return fmt.Errorf("Failed to serialize shard_outcome_root: %s", err)
+		return fmt.Errorf("Failed to serialize shard_outcome_root: %w", err)
 	}
 
 	ser_shard_outcome_root_hash := h.Sha256(ser_shard_outcome_root)
 
 	block_outcome_root, err := compute_root_from_path(h, orp, ser_shard_outcome_root_hash)
 	if err != nil {
-		return fmt.Errorf("Failed calculate block outcome root: %s", err)
+		return fmt.Errorf("Failed calculate block outcome root: %w", err)
 	}
 
 	bor := nearprimitive.CryptoHash(block_outcome_root)
 
 	if !bytes.Equal(bor.AsBytes(), ebor.AsBytes()) {
-		return fmt.Errorf("expected_block_outcome_root != block_outcome_root %v %v", ebor, bor)
+		return fmt.Errorf("%w: expected_block_outcome_root != block_outcome_root %v %v", ErrOutcomeRootMismatch, ebor, bor)
 	}
 	return nil
 }
+
+// ValidateReceipt validates the outcome of receipt_id like ValidateTransaction,
+// the receipt must have been executed by receiver_id.
+func ValidateReceipt(h nearprimitive.HostFunction, receipt_id nearprimitive.CryptoHash, receiver_id nearprimitive.AccountId, op nearprimitive.OutcomeProof, orp nearprimitive.MerklePath, ebor nearprimitive.CryptoHash) error {
+	err := check_receipt_outcome(op, receipt_id, receiver_id)
+	if err != nil {
+		return err
+	}
+
+	return ValidateTransaction(h, op, orp, ebor)
+}
+
+func check_receipt_outcome(op nearprimitive.OutcomeProof, receipt_id nearprimitive.CryptoHash, receiver_id nearprimitive.AccountId) error {
+	if op.Id != receipt_id {
+		return fmt.Errorf("%w: expected receipt %v, got %v", ErrOutcomeIdMismatch, receipt_id, op.Id)
+	}
+
+	if op.Outcome.ExecutorId != receiver_id {
+		return fmt.Errorf("%w: expected %s, got %s", ErrExecutorMismatch, receiver_id, op.Outcome.ExecutorId)
+	}
+
+	return nil
+}
+
+// VerifyInclusion proves that the outcome of proof was executed in a block
+// that head commits to: the outcome's block is the proven header, the
+// outcome is in the header's outcome root and the header is in head's block
+// merkle root.
+func VerifyInclusion(h nearprimitive.HostFunction, head nearprimitive.LightClientBlockView, proof NearTxResult) (nearprimitive.ExecutionOutcomeView, error) {
+	_, err := verify_inclusion(h, head, proof)
+	if err != nil {
+		return nearprimitive.ExecutionOutcomeView{}, err
+	}
+
+	return proof.OutcomeProof.Outcome, nil
+}
+
+// verify_inclusion returns the hash of the block the outcome was proven in
+func verify_inclusion(h nearprimitive.HostFunction, head nearprimitive.LightClientBlockView, proof NearTxResult) (nearprimitive.CryptoHash, error) {
+	header_hash, err := block_lite_view_hash(h, proof.BlockHeaderLite)
+	if err != nil {
+		return nearprimitive.CryptoHash{}, fmt.Errorf("Failed to hash block header lite: %w", err)
+	}
+
+	if header_hash != proof.OutcomeProof.BlockHash {
+		return nearprimitive.CryptoHash{}, fmt.Errorf("%w: expected %v, got %v", ErrBlockHashMismatch, header_hash, proof.OutcomeProof.BlockHash)
+	}
+
+	err = ValidateTransaction(h, proof.OutcomeProof, proof.OutcomeRootProof, proof.BlockHeaderLite.InnerLite.OutcomeRoot)
+	if err != nil {
+		return nearprimitive.CryptoHash{}, fmt.Errorf("Failed to validate outcome: %w", err)
+	}
+
+	err = verify_block_merkle_root(h, proof, head.InnerLite.BlockMerkleRoot)
+	if err != nil {
+		return nearprimitive.CryptoHash{}, fmt.Errorf("Failed to verify block merkle root: %w", err)
+	}
+
+	return header_hash, nil
+}
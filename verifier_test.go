@@ -3,12 +3,14 @@
 package light
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	base58 "github.com/btcsuite/btcutil/base58"
 	"github.com/electron-labs/near-light-client-go/mock"
 	"github.com/electron-labs/near-light-client-go/nearprimitive"
-	num "github.com/shabbyrobe/go-num"
+	borsh "github.com/near/borsh-go"
 )
 
 const (
@@ -190,6 +192,11 @@ func TestValidateTransaction(t *testing.T) {
 	outcome_root_proof = append(outcome_root_proof, nearprimitive.MerklePathItem{Hash: nearprimitive.MerkleHash(*path_item_hash), Direction: nearprimitive.Left})
 
 	serialized_status := []uint8{3, 114, 128, 19, 177, 40, 127, 16, 184, 156, 69, 215, 55, 142, 98, 142, 27, 111, 246, 232, 85, 207, 169, 209, 101, 242, 113, 144, 111, 227, 117, 100, 30}
+	status := nearprimitive.ExecutionStatusView{}
+	err = borsh.Deserialize(&status, serialized_status)
+	if err != nil {
+		t.Errorf("Failed to deserialize status: %s", err)
+	}
 	decoded_hash := base58.Decode("8hxkU4avDWFDCsZckig7oN2ypnYvLyb1qmZ3SA1t8iZK")
 
 	receipt_id := &nearprimitive.CryptoHash{}
@@ -198,7 +205,7 @@ func TestValidateTransaction(t *testing.T) {
 		t.Errorf("Failed to read receipt_id: %s", err)
 	}
 
-	tokens_burnt, _, _ := num.U128FromString("242839501800800000000")
+	tokens_burnt, _ := nearprimitive.ParseBalance("242839501800800000000")
 
 	execution_outcome := nearprimitive.ExecutionOutcomeView{
 		Logs:        []string{},
@@ -206,7 +213,7 @@ func TestValidateTransaction(t *testing.T) {
 		GasBurnt:    2428395018008,
 		TokensBurnt: tokens_burnt,
 		ExecutorId:  "relay.aurora",
-		Status:      serialized_status,
+		Status:      status,
 	}
 
 	outcome_proof := nearprimitive.OutcomeProof{
@@ -226,10 +233,102 @@ func TestValidateTransaction(t *testing.T) {
 }
 
 func TestRealValidateTransaction(t *testing.T) {
-	outcome_proof, merkle_path, expected_block_outcome_root := GetOutcomeProof(TRANSACTION_PROOF)
+	outcome_proof, merkle_path, expected_block_outcome_root, err := GetOutcomeProof(TRANSACTION_PROOF)
+	if err != nil {
+		t.Fatalf("Failed to parse outcome proof: %s", err)
+	}
 
-	err := ValidateTransaction(mock.MockHostFunction{}, outcome_proof, merkle_path, expected_block_outcome_root)
+	err = ValidateTransaction(mock.MockHostFunction{}, outcome_proof, merkle_path, expected_block_outcome_root)
 	if err != nil {
 		t.Errorf("Failed to validate transaction: %s", err)
 	}
 }
+
+func TestFailedOutcomeHash(t *testing.T) {
+	h := mock.MockHostFunction{}
+
+	success, _, _, err := GetOutcomeProof(TRANSACTION_PROOF)
+	if err != nil {
+		t.Fatalf("Failed to parse outcome proof: %s", err)
+	}
+
+	success_hash, err := calculate_execution_outcome_hash(h, success.Outcome, success.Id)
+	if err != nil {
+		t.Fatalf("Failed to hash outcome: %s", err)
+	}
+
+	failure_hashes := []nearprimitive.CryptoHash{}
+	for _, failure := range []string{
+		`{"Failure": {"ActionError": {"index": 0, "kind": {"FunctionCallError": {"ExecutionError": "Smart contract panicked"}}}}}`,
+		`{"Failure": {"InvalidTxError": "Expired"}}`,
+	} {
+		response := strings.Replace(TRANSACTION_PROOF, `"status": {
+                                        "SuccessReceiptId": "8hxkU4avDWFDCsZckig7oN2ypnYvLyb1qmZ3SA1t8iZK"
+                                }`, `"status": `+failure, 1)
+
+		proof, err := GetNearTxResult(response)
+		if err != nil {
+			t.Fatalf("Failed to parse failed outcome: %s", err)
+		}
+
+		if proof.OutcomeProof.Outcome.Status.Type() != nearprimitive.StatusFailure {
+			t.Fatalf("Unexpected status %s", proof.OutcomeProof.Outcome.Status)
+		}
+
+		hash, err := calculate_execution_outcome_hash(h, proof.OutcomeProof.Outcome, proof.OutcomeProof.Id)
+		if err != nil {
+			t.Fatalf("Failed to hash outcome: %s", err)
+		}
+
+		failure_hashes = append(failure_hashes, hash)
+	}
+
+	// Outcome hashes only commit to the failure tag
+	if failure_hashes[0] != failure_hashes[1] || failure_hashes[0] == success_hash {
+		t.Errorf("Unexpected failed outcome hashes %v, success %v", failure_hashes, success_hash)
+	}
+}
+
+func TestVerifyInclusion(t *testing.T) {
+	h := mock.MockHostFunction{}
+
+	head, err := GetClientBlockView(LIGHT_CLIENT_BLOCK)
+	if err != nil {
+		t.Fatalf("Failed to parse light client block: %s", err)
+	}
+
+	proof, err := GetNearTxResult(EXECUTION_OUTCOME)
+	if err != nil {
+		t.Fatalf("Failed to parse proof: %s", err)
+	}
+
+	outcome, err := VerifyInclusion(h, head, proof)
+	if err != nil {
+		t.Fatalf("Failed to verify inclusion: %s", err)
+	}
+
+	if outcome.ExecutorId != "partht.testnet" || outcome.GasBurnt != 2428117762192 {
+		t.Errorf("Unexpected outcome %v", outcome)
+	}
+
+	other_block := proof
+	other_block.OutcomeProof.BlockHash = nearprimitive.CryptoHash{}
+	_, err = VerifyInclusion(h, head, other_block)
+	if !errors.Is(err, ErrBlockHashMismatch) {
+		t.Errorf("Expected ErrBlockHashMismatch, got %v", err)
+	}
+
+	other_outcome := proof
+	other_outcome.OutcomeProof.Outcome.GasBurnt++
+	_, err = VerifyInclusion(h, head, other_outcome)
+	if !errors.Is(err, ErrOutcomeRootMismatch) {
+		t.Errorf("Expected ErrOutcomeRootMismatch, got %v", err)
+	}
+
+	other_head := head
+	other_head.InnerLite.BlockMerkleRoot = nearprimitive.CryptoHash{}
+	_, err = VerifyInclusion(h, other_head, proof)
+	if !errors.Is(err, ErrBlockMerkleRootMismatch) {
+		t.Errorf("Expected ErrBlockMerkleRootMismatch, got %v", err)
+	}
+}